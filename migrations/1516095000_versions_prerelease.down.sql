@@ -0,0 +1,3 @@
+ALTER TABLE versions
+  DROP COLUMN prerelease,
+  DROP COLUMN draft;
@@ -0,0 +1,3 @@
+ALTER TABLE versions
+  ADD COLUMN prerelease BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN draft      BOOLEAN NOT NULL DEFAULT FALSE;
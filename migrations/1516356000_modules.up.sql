@@ -0,0 +1,9 @@
+CREATE TABLE modules (
+  repository_id UUID         NOT NULL,
+  major         INT          NOT NULL,
+  path          VARCHAR(256) NOT NULL,
+  version       VARCHAR(64)  NOT NULL,
+  CONSTRAINT modules_repositories_id_fk FOREIGN KEY (repository_id) REFERENCES repositories (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX modules_major_uindex
+  ON modules (repository_id, major);
@@ -0,0 +1,2 @@
+DROP TABLE dependencies;
+DROP TABLE module_versions;
@@ -0,0 +1,20 @@
+CREATE TABLE module_versions (
+  path          VARCHAR(256) NOT NULL,
+  version       VARCHAR(128) NOT NULL,
+  gomod         TEXT         NOT NULL,
+  packages      JSONB,
+  license_files VARCHAR(256)[],
+  created       TIMESTAMP DEFAULT now(),
+  PRIMARY KEY (path, version)
+);
+
+CREATE TABLE dependencies (
+  module   VARCHAR(256) NOT NULL,
+  version  VARCHAR(128) NOT NULL,
+  path     VARCHAR(256) NOT NULL,
+  required VARCHAR(128) NOT NULL,
+  indirect BOOLEAN      NOT NULL DEFAULT FALSE,
+  CONSTRAINT dependencies_module_versions_fk FOREIGN KEY (module, version) REFERENCES module_versions (path, version) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX dependencies_path_uindex
+  ON dependencies (module, version, path);
@@ -0,0 +1,2 @@
+ALTER TABLE versions
+  ADD COLUMN notes TEXT NOT NULL DEFAULT '';
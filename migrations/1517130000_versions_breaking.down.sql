@@ -0,0 +1,3 @@
+ALTER TABLE versions
+  DROP COLUMN breaking,
+  DROP COLUMN api_checked;
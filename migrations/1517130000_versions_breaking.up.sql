@@ -0,0 +1,3 @@
+ALTER TABLE versions
+  ADD COLUMN breaking    BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN api_checked BOOLEAN NOT NULL DEFAULT FALSE;
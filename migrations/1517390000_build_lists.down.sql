@@ -0,0 +1,6 @@
+DROP TABLE build_lists;
+
+ALTER TABLE module_versions
+  DROP COLUMN build_list_resolved;
+
+DROP TABLE gomods;
@@ -0,0 +1,21 @@
+CREATE TABLE gomods (
+  path    VARCHAR(256) NOT NULL,
+  version VARCHAR(128) NOT NULL,
+  gomod   TEXT         NOT NULL,
+  created TIMESTAMP DEFAULT now(),
+  PRIMARY KEY (path, version)
+);
+
+ALTER TABLE module_versions
+  ADD COLUMN build_list_resolved BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE build_lists (
+  module   VARCHAR(256) NOT NULL,
+  version  VARCHAR(128) NOT NULL,
+  path     VARCHAR(256) NOT NULL,
+  selected VARCHAR(128) NOT NULL,
+  indirect BOOLEAN      NOT NULL DEFAULT FALSE,
+  CONSTRAINT build_lists_module_versions_fk FOREIGN KEY (module, version) REFERENCES module_versions (path, version) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX build_lists_path_uindex
+  ON build_lists (module, version, path);
@@ -0,0 +1,2 @@
+DROP TABLE advisory_modules;
+DROP TABLE advisories;
@@ -0,0 +1,16 @@
+CREATE TABLE advisories (
+  id       VARCHAR(128) PRIMARY KEY,
+  modified TIMESTAMP    NOT NULL,
+  advisory JSONB        NOT NULL,
+  created  TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE advisory_modules (
+  advisory_id VARCHAR(128) NOT NULL,
+  path        VARCHAR(256) NOT NULL,
+  CONSTRAINT advisory_modules_advisories_fk FOREIGN KEY (advisory_id) REFERENCES advisories (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX advisory_modules_uindex
+  ON advisory_modules (advisory_id, path);
+CREATE INDEX advisory_modules_path_index
+  ON advisory_modules (path);
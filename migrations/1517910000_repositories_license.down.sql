@@ -0,0 +1,4 @@
+ALTER TABLE repositories
+  DROP COLUMN license_name,
+  DROP COLUMN license_spdx,
+  DROP COLUMN license_url;
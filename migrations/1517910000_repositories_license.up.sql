@@ -0,0 +1,4 @@
+ALTER TABLE repositories
+  ADD COLUMN license_name VARCHAR(256) NOT NULL DEFAULT '',
+  ADD COLUMN license_spdx VARCHAR(128) NOT NULL DEFAULT '',
+  ADD COLUMN license_url  VARCHAR(512) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+DROP TABLE module_licenses;
+
+ALTER TABLE module_versions
+  DROP COLUMN licenses_detected;
@@ -0,0 +1,13 @@
+ALTER TABLE module_versions
+  ADD COLUMN licenses_detected BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE module_licenses (
+  path       VARCHAR(256) NOT NULL,
+  version    VARCHAR(128) NOT NULL,
+  file       VARCHAR(256) NOT NULL,
+  spdx       VARCHAR(128) NOT NULL,
+  confidence REAL         NOT NULL,
+  CONSTRAINT module_licenses_module_versions_fk FOREIGN KEY (path, version) REFERENCES module_versions (path, version) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX module_licenses_file_uindex
+  ON module_licenses (path, version, file, spdx);
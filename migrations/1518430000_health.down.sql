@@ -0,0 +1,9 @@
+ALTER TABLE module_versions
+  DROP COLUMN tests;
+
+DROP INDEX repositories_health_score_index;
+ALTER TABLE repositories
+  DROP COLUMN pushed,
+  DROP COLUMN health_score,
+  DROP COLUMN health,
+  DROP COLUMN health_computed;
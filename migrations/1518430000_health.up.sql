@@ -0,0 +1,10 @@
+ALTER TABLE repositories
+  ADD COLUMN pushed          TIMESTAMP,
+  ADD COLUMN health_score    SMALLINT,
+  ADD COLUMN health          JSONB,
+  ADD COLUMN health_computed TIMESTAMP;
+CREATE INDEX repositories_health_score_index
+  ON repositories (health_score);
+
+ALTER TABLE module_versions
+  ADD COLUMN tests BOOLEAN NOT NULL DEFAULT FALSE;
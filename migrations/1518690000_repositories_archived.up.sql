@@ -0,0 +1,2 @@
+ALTER TABLE repositories
+  ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+ALTER TABLE versions
+  DROP COLUMN retracted,
+  DROP COLUMN retraction;
@@ -0,0 +1,3 @@
+ALTER TABLE versions
+  ADD COLUMN retracted  BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN retraction TEXT    NOT NULL DEFAULT '';
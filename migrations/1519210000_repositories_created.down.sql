@@ -0,0 +1,5 @@
+DROP INDEX versions_published_index;
+
+DROP INDEX repositories_created_index;
+ALTER TABLE repositories
+  DROP COLUMN created;
@@ -0,0 +1,10 @@
+ALTER TABLE repositories
+  ADD COLUMN created TIMESTAMP DEFAULT now();
+UPDATE repositories SET created = coalesce(updated, now());
+ALTER TABLE repositories
+  ALTER COLUMN created SET NOT NULL;
+CREATE INDEX repositories_created_index
+  ON repositories (created);
+
+CREATE INDEX versions_published_index
+  ON versions (published);
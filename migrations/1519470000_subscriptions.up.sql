@@ -0,0 +1,27 @@
+CREATE TABLE subscriptions (
+  id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  url          VARCHAR(512)   NOT NULL,
+  format       VARCHAR(16)    NOT NULL,
+  repositories VARCHAR(256)[] NOT NULL,
+  created      TIMESTAMP      NOT NULL DEFAULT now()
+);
+CREATE INDEX subscriptions_repositories_index
+  ON subscriptions USING GIN (repositories);
+
+CREATE TABLE deliveries (
+  id              BIGSERIAL PRIMARY KEY,
+  subscription_id UUID         NOT NULL,
+  key             VARCHAR(512) NOT NULL,
+  event           JSONB        NOT NULL,
+  status          VARCHAR(16)  NOT NULL DEFAULT 'pending',
+  attempts        INT          NOT NULL DEFAULT 0,
+  next_attempt    TIMESTAMP    NOT NULL DEFAULT now(),
+  response_status INT          NOT NULL DEFAULT 0,
+  error           TEXT         NOT NULL DEFAULT '',
+  created         TIMESTAMP    NOT NULL DEFAULT now(),
+  CONSTRAINT deliveries_subscriptions_id_fk FOREIGN KEY (subscription_id) REFERENCES subscriptions (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX deliveries_key_uindex
+  ON deliveries (subscription_id, key);
+CREATE INDEX deliveries_pending_index
+  ON deliveries (next_attempt) WHERE status = 'pending';
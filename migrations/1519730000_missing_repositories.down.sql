@@ -0,0 +1 @@
+DROP TABLE missing_repositories;
@@ -0,0 +1,4 @@
+CREATE TABLE missing_repositories (
+  url     VARCHAR(256) PRIMARY KEY,
+  checked TIMESTAMP    NOT NULL DEFAULT now()
+);
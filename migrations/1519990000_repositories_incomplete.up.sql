@@ -0,0 +1,2 @@
+ALTER TABLE repositories
+  ADD COLUMN incomplete VARCHAR(64)[];
@@ -0,0 +1,2 @@
+ALTER TABLE repositories
+  DROP COLUMN watch_checked;
@@ -0,0 +1,2 @@
+ALTER TABLE repositories
+  ADD COLUMN watch_checked TIMESTAMP;
@@ -0,0 +1,4 @@
+ALTER TABLE versions
+  DROP COLUMN analysis_attempts,
+  DROP COLUMN analysis_next,
+  DROP COLUMN analysis_error;
@@ -0,0 +1,4 @@
+ALTER TABLE versions
+  ADD COLUMN analysis_attempts INT NOT NULL DEFAULT 0,
+  ADD COLUMN analysis_next     TIMESTAMP,
+  ADD COLUMN analysis_error    TEXT;
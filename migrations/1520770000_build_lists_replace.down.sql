@@ -0,0 +1,2 @@
+ALTER TABLE build_lists
+  DROP COLUMN replace;
@@ -0,0 +1,6 @@
+ALTER TABLE build_lists
+  ADD COLUMN replace VARCHAR(512);
+
+-- Build lists were resolved without the main module's replace and exclude directives
+UPDATE module_versions SET build_list_resolved = FALSE
+  WHERE gomod ~ '(^|\n)\s*(replace|exclude)\M';
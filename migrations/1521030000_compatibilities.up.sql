@@ -0,0 +1,9 @@
+CREATE TABLE compatibilities (
+  repository_id UUID        NOT NULL,
+  from_version  VARCHAR(64) NOT NULL,
+  to_version    VARCHAR(64) NOT NULL,
+  compatibility JSONB       NOT NULL,
+  created       TIMESTAMP DEFAULT now(),
+  PRIMARY KEY (repository_id, from_version, to_version),
+  CONSTRAINT compatibilities_repositories_id_fk FOREIGN KEY (repository_id) REFERENCES repositories (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
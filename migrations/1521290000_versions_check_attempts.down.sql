@@ -0,0 +1,7 @@
+DROP INDEX versions_sort_order_index;
+DROP INDEX versions_unchecked_index;
+
+ALTER TABLE versions
+  DROP COLUMN check_attempts,
+  DROP COLUMN check_next,
+  DROP COLUMN check_error;
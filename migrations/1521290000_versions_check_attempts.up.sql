@@ -0,0 +1,11 @@
+ALTER TABLE versions
+  ADD COLUMN check_attempts INT NOT NULL DEFAULT 0,
+  ADD COLUMN check_next     TIMESTAMP,
+  ADD COLUMN check_error    TEXT;
+
+-- Only versions that weren't checked yet are scanned for checks,
+-- their previous versions are looked up by their sort order
+CREATE INDEX versions_unchecked_index
+  ON versions (sort_order) WHERE NOT api_checked AND NOT prerelease AND NOT draft;
+CREATE INDEX versions_sort_order_index
+  ON versions (repository_id, sort_order);
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// writeJSON responds to a http request with a value encoded as json
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError responds to a http request with an error encoded as json
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{Error: message})
+}
+
+// writeAPIError responds with the user-facing message of an error encoded as json,
+// the same one as of the error pages. Failures of godep and the services it requests
+// are logged, as their internal details aren't exposed to clients.
+func writeAPIError(w http.ResponseWriter, r *http.Request, logger log.Logger, err error) {
+	status := errorStatus(err)
+	if status >= http.StatusInternalServerError {
+		level.Warn(logger).Log("msg", "failed to handle api request", "path", r.URL.Path, "err", err)
+	}
+	writeJSONError(w, status, newErrorPage(err).Message)
+}
+
+// RepositoryAPIHandler responds with a repository encoded as json
+func RepositoryAPIHandler(repositories Service, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uri, err := githubURL(r)
+		if err != nil {
+			writeAPIError(w, r, logger, err)
+			return
+		}
+
+		repo, err := repositories.Get(r.Context(), uri)
+		if err != nil {
+			writeAPIError(w, r, logger, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, repo)
+	}
+}
+
+// CompatibilityAPIHandler responds with the API compatibility of two versions encoded as json.
+// Versions that weren't checked before are downloaded and type checked,
+// so each client can only request a few of them.
+func CompatibilityAPIHandler(repositories Service, limiter *RateLimiter, proxies TrustedProxies, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		versions := strings.SplitN(chi.URLParam(r, "versions"), "...", 2)
+		if len(versions) != 2 || versions[0] == "" || versions[1] == "" {
+			writeJSONError(w, http.StatusBadRequest, "versions need to be compared like v1.0.0...v1.1.0")
+			return
+		}
+
+		uri, err := githubURL(r)
+		if err != nil {
+			writeAPIError(w, r, logger, err)
+			return
+		}
+
+		compat, err := repositories.StoredCompatibility(r.Context(), uri, versions[0], versions[1])
+		if err == ErrNotFound {
+			if ok, wait := limiter.Allow(proxies.ClientIP(r), time.Now()); !ok {
+				w.Header().Set("Retry-After", retryAfter(wait))
+				writeJSONError(w, http.StatusTooManyRequests, "too many compatibility checks, try again later")
+				return
+			}
+			compat, err = repositories.Compatibility(r.Context(), uri, versions[0], versions[1])
+		}
+		if err != nil {
+			writeAPIError(w, r, logger, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, compat)
+	}
+}
+
+// LicensesAPIHandler responds with the license report of a version encoded as json
+func LicensesAPIHandler(repositories Service, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uri, err := githubURL(r)
+		if err != nil {
+			writeAPIError(w, r, logger, err)
+			return
+		}
+
+		report, err := repositories.Licenses(r.Context(), uri, r.URL.Query().Get("version"))
+		if err != nil {
+			writeAPIError(w, r, logger, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// SubscribeAPIHandler creates a subscription from a json request and responds with it encoded as json
+func SubscribeAPIHandler(repositories Service, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var sub Subscription
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&sub); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "failed to decode subscription")
+			return
+		}
+
+		sub, err := repositories.Subscribe(r.Context(), sub)
+		if errors.Cause(err) == ErrInvalidSubscription {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			writeAPIError(w, r, logger, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, sub)
+	}
+}
+
+// SubscriptionAPIHandler responds with a subscription and its delivery log encoded as json
+func SubscriptionAPIHandler(repositories Service, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sub, err := repositories.Subscription(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeAPIError(w, r, logger, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, sub)
+	}
+}
+
+// UnsubscribeAPIHandler deletes a subscription
+func UnsubscribeAPIHandler(repositories Service, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := repositories.Unsubscribe(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			writeAPIError(w, r, logger, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
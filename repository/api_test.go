@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-kit/kit/log"
+)
+
+type compatibilityService struct {
+	Service
+	stored  map[string]Compatibility
+	checked int
+}
+
+func (s *compatibilityService) StoredCompatibility(ctx context.Context, url string, from, to string) (Compatibility, error) {
+	c, ok := s.stored[url+"@"+from+"..."+to]
+	if !ok {
+		return c, ErrNotFound
+	}
+	return c, nil
+}
+
+func (s *compatibilityService) Compatibility(ctx context.Context, url string, from, to string) (Compatibility, error) {
+	s.checked++
+	return Compatibility{From: from, To: to}, nil
+}
+
+func TestCompatibilityAPIHandler(t *testing.T) {
+	repositories := &compatibilityService{stored: map[string]Compatibility{
+		"github.com/foo/bar@v1.0.0...v1.1.0": {From: "v1.0.0", To: "v1.1.0"},
+	}}
+
+	r := chi.NewRouter()
+	r.Get("/api/github.com/{owner}/{name}/compatibility/{versions}", CompatibilityAPIHandler(repositories, NewRateLimiter(1, time.Hour), nil, log.NewNopLogger()))
+
+	tests := []struct {
+		url     string
+		status  int
+		checked int
+	}{
+		{url: "/api/github.com/foo/bar/compatibility/v1.0.0...v1.2.0", status: http.StatusOK, checked: 1},
+		{url: "/api/github.com/foo/bar/compatibility/v1.1.0...v1.2.0", status: http.StatusTooManyRequests, checked: 1},
+		// Stored results aren't limited
+		{url: "/api/github.com/foo/bar/compatibility/v1.0.0...v1.1.0", status: http.StatusOK, checked: 1},
+		{url: "/api/github.com/foo/bar/compatibility/v1.0.0", status: http.StatusBadRequest, checked: 1},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+		if w.Code != tt.status || repositories.checked != tt.checked {
+			t.Errorf("expected status %d after %d checks for %s, got %d after %d checks",
+				tt.status, tt.checked, tt.url, w.Code, repositories.checked)
+		}
+	}
+}
+
+type failingService struct {
+	Service
+	err error
+}
+
+func (s failingService) Get(ctx context.Context, url string) (Repository, error) {
+	return Repository{}, s.err
+}
+
+func TestRepositoryAPIHandlerErrors(t *testing.T) {
+	tests := []struct {
+		err     error
+		status  int
+		message string
+		logged  bool
+	}{
+		{err: ErrNotFound, status: http.StatusNotFound, message: newErrorPage(ErrNotFound).Message},
+		{err: &UpstreamError{Service: "GitHub", Kind: ErrRateLimited, Err: errors.New("API rate limit exceeded for 10.0.0.1")},
+			status: http.StatusBadGateway, message: "The requests godep can make to GitHub are used up right now. Please try again in a few minutes.", logged: true},
+		{err: errors.New("failed to fetch repository: pq: password authentication failed"),
+			status: http.StatusInternalServerError, message: newErrorPage(nil).Message, logged: true},
+	}
+
+	for _, tt := range tests {
+		var logs bytes.Buffer
+		r := chi.NewRouter()
+		r.Get("/api/github.com/{owner}/{name}", RepositoryAPIHandler(failingService{err: tt.err}, log.NewLogfmtLogger(&logs)))
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/github.com/foo/bar", nil))
+
+		var resp struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != tt.status || resp.Error != tt.message {
+			t.Errorf("expected status %d with %q for %v, got %d with %q", tt.status, tt.message, tt.err, w.Code, resp.Error)
+		}
+		if logged := strings.Contains(logs.String(), tt.err.Error()); logged != tt.logged {
+			t.Errorf("expected %v to be logged: %t, got logs %q", tt.err, tt.logged, logs.String())
+		}
+	}
+}
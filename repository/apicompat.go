@@ -0,0 +1,540 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Compatibility is the result of checking the exported API
+	// of two versions of a module for compatibility.
+	Compatibility struct {
+		From     string                `json:"from"`
+		To       string                `json:"to"`
+		Breaking bool                  `json:"breaking"`
+		Changes  []CompatibilityChange `json:"changes"`
+	}
+	// CompatibilityChange is a change of a package's exported API
+	CompatibilityChange struct {
+		Package  string `json:"package"`
+		Name     string `json:"name"`
+		Message  string `json:"message"`
+		Breaking bool   `json:"breaking"`
+	}
+)
+
+var reMajorSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// guessPackageName guesses the name of a package from its import path,
+// as the imported package itself isn't available.
+func guessPackageName(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if reMajorSuffix.MatchString(name) && len(parts) > 1 {
+		name = parts[len(parts)-2]
+	}
+	if i := strings.Index(name, ".v"); i > 0 {
+		name = name[:i] // gopkg.in/yaml.v2
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	return strings.Replace(name, "-", "_", -1)
+}
+
+// moduleImporter type checks the packages of a single module.
+// Packages of other modules are replaced by fake packages that contain
+// a named type for every identifier the module uses from them. This allows
+// comparing signatures without fetching all dependencies or the standard library.
+type moduleImporter struct {
+	fset       *token.FileSet
+	modulePath string
+	files      map[string][]*ast.File
+	packages   map[string]*types.Package
+	checking   map[string]bool
+	selectors  map[string]map[string]bool
+}
+
+// buildContext is the platform packages are type checked for. Files with build constraints
+// or _GOOS and _GOARCH suffixes of other platforms are left out, as they'd redeclare
+// the identifiers of the files for this platform.
+var buildContext = func() build.Context {
+	ctx := build.Default
+	ctx.GOOS = "linux"
+	ctx.GOARCH = "amd64"
+	ctx.CgoEnabled = true
+	return ctx
+}()
+
+// matchFile returns if a file is built for buildContext
+func matchFile(name string, src []byte) bool {
+	ctx := buildContext
+	ctx.OpenFile = func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(src)), nil
+	}
+	ctx.JoinPath = path.Join
+
+	match, err := ctx.MatchFile(path.Dir(name), path.Base(name))
+	return err == nil && match
+}
+
+// typeCheck type checks all packages of a module version and
+// returns them by their path relative to the module's root.
+func typeCheck(files []moduleFile, modulePath string) (map[string]*types.Package, error) {
+	mi := &moduleImporter{
+		fset:       token.NewFileSet(),
+		modulePath: modulePath,
+		files:      map[string][]*ast.File{},
+		packages:   map[string]*types.Package{},
+		checking:   map[string]bool{},
+		selectors:  map[string]map[string]bool{},
+	}
+
+	dirs := map[string]map[string]*ast.File{}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name, ".go") || strings.HasSuffix(f.Name, "_test.go") {
+			continue
+		}
+
+		rc, err := f.File.Open()
+		if err != nil {
+			return nil, err
+		}
+		src, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		if !matchFile(f.Name, src) {
+			continue
+		}
+
+		file, err := parser.ParseFile(mi.fset, f.Name, src, 0)
+		if err != nil {
+			continue
+		}
+
+		dir := path.Dir(f.Name)
+		if dirs[dir] == nil {
+			dirs[dir] = map[string]*ast.File{}
+		}
+		dirs[dir][f.Name] = file
+		mi.collectSelectors(file)
+	}
+
+	for dir, files := range dirs {
+		name := packageName(files)
+		if name == "main" {
+			continue
+		}
+
+		// Sort files by name to type check them in a stable order
+		var names []string
+		for filename, f := range files {
+			if f.Name.Name == name {
+				names = append(names, filename)
+			}
+		}
+		sort.Strings(names)
+
+		importPath := mi.modulePath
+		if dir != "." {
+			importPath = mi.modulePath + "/" + dir
+		}
+		for _, filename := range names {
+			mi.files[importPath] = append(mi.files[importPath], files[filename])
+		}
+	}
+
+	packages := map[string]*types.Package{}
+	for importPath := range mi.files {
+		rel := strings.TrimPrefix(strings.TrimPrefix(importPath, modulePath), "/")
+		if isInternal(rel) {
+			continue
+		}
+		pkg, err := mi.Import(importPath)
+		if err != nil {
+			return nil, err
+		}
+		packages[rel] = pkg
+	}
+
+	return packages, nil
+}
+
+// collectSelectors remembers all identifiers that are used from imported packages
+func (mi *moduleImporter) collectSelectors(file *ast.File) {
+	imports := map[string]string{}
+	for _, spec := range file.Imports {
+		importPath := strings.Trim(spec.Path.Value, "\"`")
+		name := guessPackageName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importPath
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if importPath, ok := imports[x.Name]; ok {
+			if mi.selectors[importPath] == nil {
+				mi.selectors[importPath] = map[string]bool{}
+			}
+			mi.selectors[importPath][sel.Sel.Name] = true
+		}
+		return true
+	})
+}
+
+// Import implements types.Importer
+func (mi *moduleImporter) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := mi.packages[importPath]; ok {
+		return pkg, nil
+	}
+
+	files, ok := mi.files[importPath]
+	if !ok {
+		pkg := types.NewPackage(importPath, guessPackageName(importPath))
+		for name := range mi.selectors[importPath] {
+			tn := types.NewTypeName(token.NoPos, pkg, name, nil)
+			types.NewNamed(tn, types.NewStruct(nil, nil), nil)
+			pkg.Scope().Insert(tn)
+		}
+		pkg.MarkComplete()
+		mi.packages[importPath] = pkg
+		return pkg, nil
+	}
+
+	if mi.checking[importPath] {
+		return nil, errors.Errorf("import cycle through %s", importPath)
+	}
+	mi.checking[importPath] = true
+
+	conf := types.Config{
+		Importer:         mi,
+		IgnoreFuncBodies: true,
+		FakeImportC:      true,
+		// Errors are expected, as imported packages are incomplete
+		Error: func(error) {},
+	}
+	pkg, _ := conf.Check(importPath, mi.fset, files, nil)
+	mi.packages[importPath] = pkg
+
+	return pkg, nil
+}
+
+// compareAPI compares the exported API of two versions of a module's packages
+func compareAPI(from, to map[string]*types.Package, fromPath, toPath string) []CompatibilityChange {
+	var changes []CompatibilityChange
+
+	var rels []string
+	for rel := range from {
+		rels = append(rels, rel)
+	}
+	for rel := range to {
+		if _, ok := from[rel]; !ok {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	for _, rel := range rels {
+		fp, tp := from[rel], to[rel]
+		importPath := toPath
+		if rel != "" {
+			importPath = toPath + "/" + rel
+		}
+
+		switch {
+		case tp == nil:
+			changes = append(changes, CompatibilityChange{
+				Package:  strings.TrimSuffix(fromPath+"/"+rel, "/"),
+				Message:  "package removed",
+				Breaking: true,
+			})
+		case fp == nil:
+			changes = append(changes, CompatibilityChange{Package: importPath, Message: "package added"})
+		default:
+			c := apiComparer{
+				from: qualifier(fp, fromPath),
+				to:   qualifier(tp, toPath),
+			}
+			for _, change := range c.packages(fp, tp) {
+				change.Package = importPath
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	return changes
+}
+
+// qualifier returns a types.Qualifier that qualifies types of the module
+// relative to the module's root, so that types of different major versions
+// are printed the same.
+func qualifier(pkg *types.Package, modulePath string) types.Qualifier {
+	return func(p *types.Package) string {
+		switch {
+		case p == pkg:
+			return ""
+		case p.Path() == modulePath:
+			return "."
+		case strings.HasPrefix(p.Path(), modulePath+"/"):
+			return "./" + strings.TrimPrefix(p.Path(), modulePath+"/")
+		default:
+			return p.Path()
+		}
+	}
+}
+
+type apiComparer struct {
+	from types.Qualifier
+	to   types.Qualifier
+}
+
+func (c apiComparer) packages(from, to *types.Package) []CompatibilityChange {
+	var changes []CompatibilityChange
+	fs, ts := from.Scope(), to.Scope()
+
+	for _, name := range fs.Names() {
+		if !ast.IsExported(name) {
+			continue
+		}
+		fobj, tobj := fs.Lookup(name), ts.Lookup(name)
+		if tobj == nil {
+			changes = append(changes, CompatibilityChange{Name: name, Message: "removed", Breaking: true})
+			continue
+		}
+		changes = append(changes, c.objects(name, fobj, tobj)...)
+	}
+	for _, name := range ts.Names() {
+		if ast.IsExported(name) && fs.Lookup(name) == nil {
+			changes = append(changes, CompatibilityChange{Name: name, Message: "added"})
+		}
+	}
+
+	return changes
+}
+
+func (c apiComparer) objects(name string, from, to types.Object) []CompatibilityChange {
+	breaking := func(format string, args ...interface{}) []CompatibilityChange {
+		return []CompatibilityChange{{Name: name, Message: fmt.Sprintf(format, args...), Breaking: true}}
+	}
+
+	switch fo := from.(type) {
+	case *types.Const:
+		tc, ok := to.(*types.Const)
+		if !ok {
+			return breaking("changed from a constant to a %s", objectKind(to))
+		}
+		if ft, tt := types.TypeString(fo.Type(), c.from), types.TypeString(tc.Type(), c.to); ft != tt {
+			return breaking("type changed from %s to %s", ft, tt)
+		}
+		if fo.Val().ExactString() != tc.Val().ExactString() {
+			return breaking("value changed from %s to %s", fo.Val().ExactString(), tc.Val().ExactString())
+		}
+	case *types.Var:
+		tv, ok := to.(*types.Var)
+		if !ok {
+			return breaking("changed from a variable to a %s", objectKind(to))
+		}
+		if ft, tt := types.TypeString(fo.Type(), c.from), types.TypeString(tv.Type(), c.to); ft != tt {
+			return breaking("type changed from %s to %s", ft, tt)
+		}
+	case *types.Func:
+		tf, ok := to.(*types.Func)
+		if !ok {
+			return breaking("changed from a function to a %s", objectKind(to))
+		}
+		if fs, ts := typeString(fo.Type(), c.from), typeString(tf.Type(), c.to); fs != ts {
+			return breaking("signature changed from %s to %s", fs, ts)
+		}
+	case *types.TypeName:
+		tt, ok := to.(*types.TypeName)
+		if !ok {
+			return breaking("changed from a type to a %s", objectKind(to))
+		}
+		return c.types(name, fo.Type(), tt.Type())
+	}
+
+	return nil
+}
+
+func (c apiComparer) types(name string, from, to types.Type) []CompatibilityChange {
+	var changes []CompatibilityChange
+	change := func(breaking bool, format string, args ...interface{}) {
+		changes = append(changes, CompatibilityChange{Name: name, Message: fmt.Sprintf(format, args...), Breaking: breaking})
+	}
+
+	switch fu := from.Underlying().(type) {
+	case *types.Struct:
+		tu, ok := to.Underlying().(*types.Struct)
+		if !ok {
+			change(true, "changed from a struct to %s", types.TypeString(to.Underlying(), c.to))
+			return changes
+		}
+
+		fields := map[string]*types.Var{}
+		for i := 0; i < tu.NumFields(); i++ {
+			fields[tu.Field(i).Name()] = tu.Field(i)
+		}
+		for i := 0; i < fu.NumFields(); i++ {
+			ff := fu.Field(i)
+			if !ff.Exported() {
+				continue
+			}
+			tf, ok := fields[ff.Name()]
+			if !ok || !tf.Exported() {
+				change(true, "field %s removed", ff.Name())
+				continue
+			}
+			if ft, tt := types.TypeString(ff.Type(), c.from), types.TypeString(tf.Type(), c.to); ft != tt {
+				change(true, "field %s changed its type from %s to %s", ff.Name(), ft, tt)
+			}
+			delete(fields, ff.Name())
+		}
+		for fieldName, f := range fields {
+			if f.Exported() {
+				change(false, "field %s added", fieldName)
+			}
+		}
+	case *types.Interface:
+		tu, ok := to.Underlying().(*types.Interface)
+		if !ok {
+			change(true, "changed from an interface to %s", types.TypeString(to.Underlying(), c.to))
+			return changes
+		}
+
+		methods := map[string]*types.Func{}
+		for i := 0; i < tu.NumMethods(); i++ {
+			methods[tu.Method(i).Name()] = tu.Method(i)
+		}
+		for i := 0; i < fu.NumMethods(); i++ {
+			fm := fu.Method(i)
+			tm, ok := methods[fm.Name()]
+			if !ok {
+				change(true, "method %s removed from interface", fm.Name())
+				continue
+			}
+			if fs, ts := typeString(fm.Type(), c.from), typeString(tm.Type(), c.to); fs != ts {
+				change(true, "method %s changed its signature from %s to %s", fm.Name(), fs, ts)
+			}
+			delete(methods, fm.Name())
+		}
+		// Every added method breaks existing implementations of the interface
+		for methodName := range methods {
+			change(true, "method %s added to interface", methodName)
+		}
+		return sortChanges(changes)
+	default:
+		if ft, tt := types.TypeString(from.Underlying(), c.from), types.TypeString(to.Underlying(), c.to); ft != tt {
+			change(true, "changed from %s to %s", ft, tt)
+		}
+	}
+
+	// Compare the methods of both the type and its pointer,
+	// as a method moved to a pointer receiver breaks values.
+	for _, pointer := range []bool{false, true} {
+		ft, tt := from, to
+		receiver := name
+		if pointer {
+			ft, tt = types.NewPointer(from), types.NewPointer(to)
+			receiver = "*" + name
+		}
+
+		fms, tms := types.NewMethodSet(ft), types.NewMethodSet(tt)
+		for i := 0; i < fms.Len(); i++ {
+			fm := fms.At(i).Obj()
+			if !fm.Exported() {
+				continue
+			}
+			tsel := lookupMethod(tms, fm.Name())
+			if tsel == nil {
+				change(true, "method %s removed from %s", fm.Name(), receiver)
+				continue
+			}
+			if fs, ts := typeString(fm.Type(), c.from), typeString(tsel.Obj().Type(), c.to); fs != ts {
+				change(true, "method %s of %s changed its signature from %s to %s", fm.Name(), receiver, fs, ts)
+			}
+		}
+		if pointer {
+			for i := 0; i < tms.Len(); i++ {
+				tm := tms.At(i).Obj()
+				if tm.Exported() && lookupMethod(fms, tm.Name()) == nil {
+					change(false, "method %s added to %s", tm.Name(), receiver)
+				}
+			}
+		}
+	}
+
+	return sortChanges(changes)
+}
+
+// typeString returns the string of a type. The names of a signature's
+// parameters are left out, as renaming them doesn't change the API.
+func typeString(t types.Type, q types.Qualifier) string {
+	sig, ok := t.(*types.Signature)
+	if !ok {
+		return types.TypeString(t, q)
+	}
+
+	unnamed := func(t *types.Tuple) *types.Tuple {
+		vars := make([]*types.Var, t.Len())
+		for i := range vars {
+			vars[i] = types.NewParam(token.NoPos, nil, "", t.At(i).Type())
+		}
+		return types.NewTuple(vars...)
+	}
+
+	return types.TypeString(types.NewSignature(nil, unnamed(sig.Params()), unnamed(sig.Results()), sig.Variadic()), q)
+}
+
+func lookupMethod(ms *types.MethodSet, name string) *types.Selection {
+	for i := 0; i < ms.Len(); i++ {
+		if ms.At(i).Obj().Name() == name {
+			return ms.At(i)
+		}
+	}
+	return nil
+}
+
+func sortChanges(changes []CompatibilityChange) []CompatibilityChange {
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Message < changes[j].Message
+	})
+	return changes
+}
+
+func objectKind(o types.Object) string {
+	switch o.(type) {
+	case *types.Const:
+		return "constant"
+	case *types.Var:
+		return "variable"
+	case *types.Func:
+		return "function"
+	case *types.TypeName:
+		return "type"
+	default:
+		return "declaration"
+	}
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"go/types"
+	"testing"
+)
+
+func TestCompareAPI(t *testing.T) {
+	typeCheckZip := func(version string, files map[string]string) map[string]*types.Package {
+		prefix := "github.com/foo/bar@" + version + "/"
+		zr := testModuleZip(t, prefix, files)
+		packages, err := typeCheck(moduleFiles(zr, "github.com/foo/bar", version), "github.com/foo/bar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return packages
+	}
+
+	from := typeCheckZip("v1.0.0", map[string]string{
+		"go.mod": "module github.com/foo/bar\n",
+		"bar.go": `package bar
+
+import "github.com/pkg/errors"
+
+type Config struct {
+	Name string
+}
+
+type Store interface {
+	Get(key string) (string, error)
+}
+
+func New(c Config) (*Client, error) { return nil, errors.New("nope") }
+
+func Remove() {}
+
+type Client struct{}
+
+func (c *Client) Do(s Store) error { return nil }
+`,
+		"internal/util/util.go": "package util\n\nfunc Help() {}\n",
+	})
+
+	to := typeCheckZip("v1.1.0", map[string]string{
+		"go.mod": "module github.com/foo/bar\n",
+		"bar.go": `package bar
+
+import "github.com/pkg/errors"
+
+type Config struct {
+	Name    string
+	Timeout int
+}
+
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+}
+
+func New(c Config) (*Client, error) { return nil, errors.New("nope") }
+
+type Client struct{}
+
+func (c *Client) Do(s Store) error { return nil }
+
+func (c *Client) Close() error { return nil }
+`,
+		"baz/baz.go": "package baz\n\nconst Answer = 42\n",
+	})
+
+	expected := []CompatibilityChange{
+		{Package: "github.com/foo/bar", Name: "Client", Message: "method Close added to *Client"},
+		{Package: "github.com/foo/bar", Name: "Config", Message: "field Timeout added"},
+		{Package: "github.com/foo/bar", Name: "Remove", Message: "removed", Breaking: true},
+		{Package: "github.com/foo/bar", Name: "Store", Message: "method Set added to interface", Breaking: true},
+		{Package: "github.com/foo/bar/baz", Message: "package added"},
+	}
+
+	changes := compareAPI(from, to, "github.com/foo/bar", "github.com/foo/bar")
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i, c := range expected {
+		if changes[i] != c {
+			t.Errorf("expected change %+v, got %+v", c, changes[i])
+		}
+	}
+}
+
+func TestCompareAPISignatures(t *testing.T) {
+	typeCheckZip := func(version, src string) map[string]*types.Package {
+		zr := testModuleZip(t, "github.com/foo/bar@"+version+"/", map[string]string{"bar.go": src})
+		packages, err := typeCheck(moduleFiles(zr, "github.com/foo/bar", version), "github.com/foo/bar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return packages
+	}
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		breaking bool
+	}{
+		{name: "unchanged", from: "func F(a int) {}", to: "func F(b int) {}", breaking: false},
+		{name: "parameter added", from: "func F(a int) {}", to: "func F(a, b int) {}", breaking: true},
+		{name: "result changed", from: "func F() int { return 0 }", to: "func F() int64 { return 0 }", breaking: true},
+		{name: "constant value", from: "const C = 1", to: "const C = 2", breaking: true},
+		{name: "pointer receiver", from: "type T struct{}\nfunc (T) M() {}", to: "type T struct{}\nfunc (*T) M() {}", breaking: true},
+		{name: "field type", from: "type T struct{ A int }", to: "type T struct{ A string }", breaking: true},
+		{name: "unexported", from: "func f() {}", to: "func g() {}", breaking: false},
+	}
+
+	for _, tt := range tests {
+		from := typeCheckZip("v1.0.0", "package bar\n\n"+tt.from+"\n")
+		to := typeCheckZip("v1.0.1", "package bar\n\n"+tt.to+"\n")
+
+		breaking := false
+		for _, c := range compareAPI(from, to, "github.com/foo/bar", "github.com/foo/bar") {
+			breaking = breaking || c.Breaking
+		}
+		if breaking != tt.breaking {
+			t.Errorf("%s: expected breaking to be %t", tt.name, tt.breaking)
+		}
+	}
+}
+
+func TestTypeCheckBuildConstraints(t *testing.T) {
+	zr := testModuleZip(t, "github.com/foo/bar@v1.0.0/", map[string]string{
+		"bar.go":         "package bar\n\nfunc Common() {}\n",
+		"bar_linux.go":   "package bar\n\nfunc Platform() int { return 0 }\n",
+		"bar_windows.go": "package bar\n\nfunc Platform() string { return \"\" }\n",
+		"bar_arm64.go":   "package bar\n\nfunc Platform() bool { return false }\n",
+		"tagged.go":      "// +build darwin\n\npackage bar\n\nfunc Platform() {}\n",
+		"gen.go":         "//go:build ignore\n\npackage main\n\nfunc main() {}\n",
+	})
+	packages, err := typeCheck(moduleFiles(zr, "github.com/foo/bar", "v1.0.0"), "github.com/foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := packages[""]
+	if pkg == nil {
+		t.Fatalf("expected the root package, got %v", packages)
+	}
+	f, ok := pkg.Scope().Lookup("Platform").(*types.Func)
+	if !ok {
+		t.Fatal("expected Platform to be declared")
+	}
+	if res := f.Type().(*types.Signature).Results(); res.Len() != 1 || res.At(0).Type().String() != "int" {
+		t.Errorf("expected Platform of linux/amd64 returning int, got %s", f.Type())
+	}
+}
@@ -0,0 +1,26 @@
+package repository
+
+import "time"
+
+// Attempt records the failed attempts of a task running in the background,
+// like analyzing a version, so that failing tasks are retried with backoff
+// and given up eventually instead of being retried over and over again.
+type Attempt struct {
+	Count int
+	Next  time.Time
+	Error string
+}
+
+// due returns if a task should be attempted again
+func (a Attempt) due(now time.Time, max int) bool {
+	return a.Count < max && !now.Before(a.Next)
+}
+
+// failed returns the attempt updated by another failure.
+// Tasks are retried with the same backoff as deliveries.
+func (a Attempt) failed(err error, now time.Time) Attempt {
+	a.Count++
+	a.Next = now.Add(deliveryBackoff(a.Count))
+	a.Error = err.Error()
+	return a
+}
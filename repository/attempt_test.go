@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAttempt(t *testing.T) {
+	now := time.Now()
+
+	var a Attempt
+	if !a.due(now, 3) {
+		t.Error("expected a task never attempted to be due")
+	}
+
+	a = a.failed(errors.New("proxy unavailable"), now)
+	if a.Count != 1 || a.Error != "proxy unavailable" {
+		t.Errorf("unexpected attempt: %+v", a)
+	}
+	if a.due(now, 3) || !a.due(now.Add(time.Minute), 3) {
+		t.Errorf("expected the task to be due again after a minute, got %s", a.Next)
+	}
+
+	a = a.failed(errors.New("proxy unavailable"), now)
+	a = a.failed(errors.New("proxy unavailable"), now)
+	if a.due(now.Add(24*time.Hour), 3) {
+		t.Error("expected the task to be given up after 3 attempts")
+	}
+}
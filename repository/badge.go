@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+)
+
+// Badge is a small image showing a label and a message, like shields.io badges
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// Colors of badges
+const (
+	badgeBlue   = "#007ec6"
+	badgeGreen  = "#4c1"
+	badgeYellow = "#dfb317"
+	badgeOrange = "#fe7d37"
+	badgeRed    = "#e05d44"
+	badgeGrey   = "#9f9f9f"
+)
+
+// badgeMaxAge is the number of seconds a badge may be cached by clients and proxies
+const badgeMaxAge = 3600
+
+var badgeTmpl = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{ .Width }}" height="20" role="img" aria-label="{{ .Label }}: {{ .Message }}">
+<title>{{ .Label }}: {{ .Message }}</title>
+<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="{{ .Width }}" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)">
+<rect width="{{ .LabelWidth }}" height="20" fill="#555"/>
+<rect x="{{ .LabelWidth }}" width="{{ .MessageWidth }}" height="20" fill="{{ .Color }}"/>
+<rect width="{{ .Width }}" height="20" fill="url(#s)"/>
+</g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana, Geneva, DejaVu Sans, sans-serif" font-size="11">
+<text x="{{ .LabelX }}" y="15" fill="#010101" fill-opacity=".3">{{ .Label }}</text>
+<text x="{{ .LabelX }}" y="14">{{ .Label }}</text>
+<text x="{{ .MessageX }}" y="15" fill="#010101" fill-opacity=".3">{{ .Message }}</text>
+<text x="{{ .MessageX }}" y="14">{{ .Message }}</text>
+</g>
+</svg>
+`))
+
+// badgeTextWidth estimates the width of a text in pixels, as the font's metrics aren't available
+func badgeTextWidth(s string) int {
+	return len([]rune(s))*7 + 10
+}
+
+// SVG renders the badge as svg image
+func (b Badge) SVG() ([]byte, error) {
+	labelWidth, messageWidth := badgeTextWidth(b.Label), badgeTextWidth(b.Message)
+
+	var buf bytes.Buffer
+	err := badgeTmpl.Execute(&buf, struct {
+		Badge
+		Width, LabelWidth, MessageWidth int
+		LabelX, MessageX                int
+	}{
+		Badge:        b,
+		Width:        labelWidth + messageWidth,
+		LabelWidth:   labelWidth,
+		MessageWidth: messageWidth,
+		LabelX:       labelWidth / 2,
+		MessageX:     labelWidth + messageWidth/2,
+	})
+
+	return buf.Bytes(), err
+}
+
+// repositoryBadge returns one of the badges of a repository by its name, or false if there's no such badge
+func repositoryBadge(repo Repository, name string) (Badge, bool) {
+	switch name {
+	case "version":
+		b := Badge{Label: "version", Message: "none", Color: badgeGrey}
+		if v := repo.CurrentVersion.Name; v != "" {
+			b.Message, b.Color = v, badgeBlue
+		}
+		return b, true
+	case "license":
+		b := Badge{Label: "license", Message: "unknown", Color: badgeGrey}
+		if l := repo.License.SPDX; l != "" && l != "NOASSERTION" {
+			b.Message = l
+			switch licenseCategory(l) {
+			case Permissive:
+				b.Color = badgeGreen
+			case WeakCopyleft:
+				b.Color = badgeYellow
+			case StrongCopyleft:
+				b.Color = badgeOrange
+			}
+		}
+		return b, true
+	case "importers":
+		b := Badge{Label: "importers", Message: "0", Color: badgeBlue}
+		for _, s := range repo.Statistics {
+			if s.Name == "Importers" {
+				b.Message = metricFormat(s.Value)
+			}
+		}
+		return b, true
+	case "health":
+		b := Badge{Label: "health", Message: "unknown", Color: badgeGrey}
+		if len(repo.Health.Components) > 0 {
+			b.Message = strconv.Itoa(repo.Health.Score) + "/100"
+			switch score := repo.Health.Score; {
+			case score >= 80:
+				b.Color = badgeGreen
+			case score >= 60:
+				b.Color = badgeYellow
+			case score >= 40:
+				b.Color = badgeOrange
+			default:
+				b.Color = badgeRed
+			}
+		}
+		return b, true
+	}
+	return Badge{}, false
+}
+
+// metricFormat shortens large numbers, like 1234 to 1.2k
+func metricFormat(n int) string {
+	switch {
+	case n >= 1000000:
+		return strconv.FormatFloat(float64(n)/1000000, 'f', 1, 64) + "M"
+	case n >= 1000:
+		return strconv.FormatFloat(float64(n)/1000, 'f', 1, 64) + "k"
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+// BadgeHandler responds with a svg badge of a repository.
+// Badges are cached for an hour and revalidated by their ETag.
+func BadgeHandler(repositories Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uri, err := githubURL(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		name := chi.URLParam(r, "badge")
+		if _, ok := repositoryBadge(Repository{}, name); !ok {
+			http.Error(w, "badge needs to be one of version, license, importers or health", http.StatusNotFound)
+			return
+		}
+
+		repo, err := repositories.Get(r.Context(), uri)
+		_, unavailable := upstream(err)
+		if err != nil && err != ErrNotFound && !unavailable {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		status := http.StatusOK
+		badge, _ := repositoryBadge(repo, name)
+		// Unknown repositories still get a badge, so that READMEs don't show broken images
+		if err == ErrNotFound {
+			badge.Message, badge.Color = "not found", badgeGrey
+			status = http.StatusNotFound
+		}
+		if unavailable {
+			badge.Message, badge.Color = "unavailable", badgeGrey
+			status = errorStatus(err)
+		}
+
+		svg, err := badge.SVG()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		etag := fmt.Sprintf(`"%x"`, sha1.Sum(svg))
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", badgeMaxAge))
+		if unavailable {
+			// The badge is available again once the service recovers
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		w.Header().Set("ETag", etag)
+		if status == http.StatusOK && r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(status)
+		w.Write(svg)
+	}
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestRepositoryBadge(t *testing.T) {
+	repo := Repository{
+		CurrentVersion: Version{Name: "v1.2.0"},
+		License:        License{SPDX: "GPL-3.0"},
+		Statistics:     []Statistic{{Name: "Importers", Value: 12345}},
+		Health:         Health{Score: 72, Components: []HealthComponent{{Name: "go.mod"}}},
+	}
+
+	expected := map[string]Badge{
+		"version":   {Label: "version", Message: "v1.2.0", Color: badgeBlue},
+		"license":   {Label: "license", Message: "GPL-3.0", Color: badgeOrange},
+		"importers": {Label: "importers", Message: "12.3k", Color: badgeBlue},
+		"health":    {Label: "health", Message: "72/100", Color: badgeYellow},
+	}
+	for name, b := range expected {
+		badge, ok := repositoryBadge(repo, name)
+		if !ok {
+			t.Errorf("expected a %s badge", name)
+			continue
+		}
+		if badge != b {
+			t.Errorf("expected %s badge %+v, got %+v", name, b, badge)
+		}
+	}
+
+	if b, _ := repositoryBadge(Repository{}, "health"); b.Message != "unknown" {
+		t.Errorf("expected the health of a repository without health to be unknown, got %s", b.Message)
+	}
+	if _, ok := repositoryBadge(repo, "stars"); ok {
+		t.Error("expected no stars badge")
+	}
+}
+
+func TestBadgeSVG(t *testing.T) {
+	svg, err := Badge{Label: "license", Message: "<unknown>", Color: badgeGrey}.SVG()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Width string `xml:"width,attr"`
+		Title string `xml:"title"`
+	}
+	if err := xml.Unmarshal(svg, &doc); err != nil {
+		t.Fatalf("failed to parse svg: %v\n%s", err, svg)
+	}
+	if doc.Title != "license: <unknown>" {
+		t.Errorf("expected the title to be escaped, got %q", doc.Title)
+	}
+	if doc.Width != "132" {
+		t.Errorf("expected a width of 132, got %s", doc.Width)
+	}
+}
+
+func TestMetricFormat(t *testing.T) {
+	tests := map[int]string{
+		0:       "0",
+		999:     "999",
+		1000:    "1.0k",
+		12345:   "12.3k",
+		2500000: "2.5M",
+	}
+
+	for n, expected := range tests {
+		if s := metricFormat(n); s != expected {
+			t.Errorf("expected %d to be formatted as %s, got %s", n, expected, s)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+)
+
+type (
+	// Comparison lists the changes between two versions of a Repository
+	Comparison struct {
+		From         ModuleVersion
+		To           ModuleVersion
+		Dependencies []DependencyChange
+		API          []APIChange
+		Releases     []Version
+	}
+	// DependencyChange is a dependency that was added, removed or
+	// changed its version. From is empty for added dependencies,
+	// To is empty for removed ones.
+	DependencyChange struct {
+		Path string
+		From string
+		To   string
+	}
+	// APIChange is an exported declaration of a package that was added,
+	// removed or changed. Whole packages that were added or removed
+	// are changes of the kind package.
+	APIChange struct {
+		Package string
+		Kind    string
+		Name    string
+		From    string
+		To      string
+	}
+)
+
+// KindPackage is the kind of an APIChange of a whole package
+const KindPackage = "package"
+
+// Added returns true if the dependency is new
+func (c DependencyChange) Added() bool { return c.From == "" }
+
+// Removed returns true if the dependency isn't required anymore
+func (c DependencyChange) Removed() bool { return c.To == "" }
+
+// Added returns true if the declaration or package is new
+func (c APIChange) Added() bool { return c.From == "" }
+
+// Removed returns true if the declaration or package doesn't exist anymore
+func (c APIChange) Removed() bool { return c.To == "" }
+
+// diffDependencies returns all dependencies that changed, sorted by their path
+func diffDependencies(from, to []Dependency) []DependencyChange {
+	versions := map[string]*DependencyChange{}
+	for _, d := range from {
+		versions[d.Path] = &DependencyChange{Path: d.Path, From: d.Version}
+	}
+	for _, d := range to {
+		if c, ok := versions[d.Path]; ok {
+			c.To = d.Version
+			continue
+		}
+		versions[d.Path] = &DependencyChange{Path: d.Path, To: d.Version}
+	}
+
+	var changes []DependencyChange
+	for _, c := range versions {
+		if c.From != c.To {
+			changes = append(changes, *c)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
+
+// diffPackages returns all exported declarations that changed between two
+// versions of a module. Packages are matched by their path inside the module,
+// as the module path itself changes with a new major version.
+func diffPackages(from, to ModuleVersion) []APIChange {
+	relative := func(mv ModuleVersion) map[string]Package {
+		packages := map[string]Package{}
+		for _, p := range mv.Packages {
+			rel := strings.TrimPrefix(strings.TrimPrefix(p.ImportPath, mv.Path), "/")
+			packages[rel] = p
+		}
+		return packages
+	}
+	fromPackages, toPackages := relative(from), relative(to)
+
+	var changes []APIChange
+	for rel, fp := range fromPackages {
+		tp, ok := toPackages[rel]
+		if !ok {
+			changes = append(changes, APIChange{Package: fp.ImportPath, Kind: KindPackage, Name: fp.ImportPath, From: fp.ImportPath})
+			continue
+		}
+		changes = append(changes, diffDeclarations(tp.ImportPath, fp.Declarations, tp.Declarations)...)
+	}
+	for rel, tp := range toPackages {
+		if _, ok := fromPackages[rel]; !ok {
+			changes = append(changes, APIChange{Package: tp.ImportPath, Kind: KindPackage, Name: tp.ImportPath, To: tp.ImportPath})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Package != changes[j].Package {
+			return changes[i].Package < changes[j].Package
+		}
+		return changes[i].Name < changes[j].Name
+	})
+
+	return changes
+}
+
+func diffDeclarations(pkg string, from, to []Declaration) []APIChange {
+	decls := map[string]*APIChange{}
+	for _, d := range from {
+		decls[d.Kind+" "+d.Name] = &APIChange{Package: pkg, Kind: d.Kind, Name: d.Name, From: d.Decl}
+	}
+	for _, d := range to {
+		if c, ok := decls[d.Kind+" "+d.Name]; ok {
+			c.To = d.Decl
+			continue
+		}
+		decls[d.Kind+" "+d.Name] = &APIChange{Package: pkg, Kind: d.Kind, Name: d.Name, To: d.Decl}
+	}
+
+	var changes []APIChange
+	for _, c := range decls {
+		if c.From != c.To {
+			changes = append(changes, *c)
+		}
+	}
+	return changes
+}
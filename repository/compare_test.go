@@ -0,0 +1,79 @@
+package repository
+
+import "testing"
+
+func TestDiffDependencies(t *testing.T) {
+	from := []Dependency{
+		{Path: "github.com/pkg/errors", Version: "v0.8.0"},
+		{Path: "github.com/go-kit/kit", Version: "v0.6.0"},
+		{Path: "golang.org/x/sync", Version: "v0.1.0"},
+	}
+	to := []Dependency{
+		{Path: "github.com/pkg/errors", Version: "v0.9.1"},
+		{Path: "golang.org/x/sync", Version: "v0.1.0"},
+		{Path: "github.com/oklog/run", Version: "v1.0.0"},
+	}
+
+	expected := []DependencyChange{
+		{Path: "github.com/go-kit/kit", From: "v0.6.0"},
+		{Path: "github.com/oklog/run", To: "v1.0.0"},
+		{Path: "github.com/pkg/errors", From: "v0.8.0", To: "v0.9.1"},
+	}
+
+	changes := diffDependencies(from, to)
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i, c := range expected {
+		if changes[i] != c {
+			t.Errorf("expected change %+v, got %+v", c, changes[i])
+		}
+	}
+	if !changes[0].Removed() || !changes[1].Added() || changes[2].Added() || changes[2].Removed() {
+		t.Errorf("changes are not flagged correctly: %+v", changes)
+	}
+}
+
+func TestDiffPackages(t *testing.T) {
+	from := ModuleVersion{
+		Path: "github.com/foo/bar",
+		Packages: []Package{{
+			ImportPath: "github.com/foo/bar",
+			Declarations: []Declaration{
+				{Kind: KindFunc, Name: "New", Decl: "func New() *Bar"},
+				{Kind: KindFunc, Name: "Old", Decl: "func Old()"},
+				{Kind: KindType, Name: "Bar", Decl: "type Bar struct{}"},
+			},
+		}, {
+			ImportPath: "github.com/foo/bar/gone",
+		}},
+	}
+	to := ModuleVersion{
+		Path: "github.com/foo/bar/v2",
+		Packages: []Package{{
+			ImportPath: "github.com/foo/bar/v2",
+			Declarations: []Declaration{
+				{Kind: KindFunc, Name: "New", Decl: "func New(name string) *Bar"},
+				{Kind: KindType, Name: "Bar", Decl: "type Bar struct{}"},
+				{Kind: KindMethod, Name: "Bar.Do", Decl: "func (b *Bar) Do()"},
+			},
+		}},
+	}
+
+	expected := []APIChange{
+		{Package: "github.com/foo/bar/gone", Kind: KindPackage, Name: "github.com/foo/bar/gone", From: "github.com/foo/bar/gone"},
+		{Package: "github.com/foo/bar/v2", Kind: KindMethod, Name: "Bar.Do", To: "func (b *Bar) Do()"},
+		{Package: "github.com/foo/bar/v2", Kind: KindFunc, Name: "New", From: "func New() *Bar", To: "func New(name string) *Bar"},
+		{Package: "github.com/foo/bar/v2", Kind: KindFunc, Name: "Old", From: "func Old()"},
+	}
+
+	changes := diffPackages(from, to)
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i, c := range expected {
+		if changes[i] != c {
+			t.Errorf("expected change %+v, got %+v", c, changes[i])
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"archive/zip"
+	"bytes"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+type (
+	// Package is the documentation of a Go package
+	Package struct {
+		ImportPath   string
+		Name         string
+		Synopsis     string
+		Doc          string
+		Declarations []Declaration
+	}
+	// Declaration is an exported identifier of a Package.
+	// Methods are named after their receiver's type, like Type.Method.
+	Declaration struct {
+		Kind string
+		Name string
+		Decl string
+		Doc  string
+	}
+)
+
+// Kinds of a Declaration
+const (
+	KindConst  = "const"
+	KindVar    = "var"
+	KindFunc   = "func"
+	KindType   = "type"
+	KindMethod = "method"
+)
+
+// moduleFile is a file of a module zip with its path relative to the module root
+type moduleFile struct {
+	Name string
+	File *zip.File
+}
+
+// moduleFiles returns the files of a module zip relative to the module's root.
+// Files of nested modules, vendor and testdata directories are skipped.
+func moduleFiles(zr *zip.Reader, modulePath, version string) []moduleFile {
+	prefix := modulePath + "@" + version + "/"
+
+	nested := map[string]bool{}
+	for _, f := range zr.File {
+		name := strings.TrimPrefix(f.Name, prefix)
+		if path.Base(name) == "go.mod" && path.Dir(name) != "." {
+			nested[path.Dir(name)] = true
+		}
+	}
+
+	var files []moduleFile
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(f.Name, prefix)
+
+		skip := false
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			base := path.Base(dir)
+			if nested[dir] || base == "vendor" || base == "testdata" ||
+				strings.HasPrefix(base, "_") || strings.HasPrefix(base, ".") {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			files = append(files, moduleFile{Name: name, File: f})
+		}
+	}
+
+	return files
+}
+
+// isInternal returns true if a package can't be imported from other modules
+func isInternal(dir string) bool {
+	for _, part := range strings.Split(dir, "/") {
+		if part == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePackages parses the Go files of a module and returns the documentation
+// of all its packages that can be imported by other modules.
+func parsePackages(files []moduleFile, modulePath string) ([]Package, error) {
+	fset := token.NewFileSet()
+	dirs := map[string]map[string]*ast.File{}
+
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name, ".go") || strings.HasSuffix(f.Name, "_test.go") {
+			continue
+		}
+		dir := path.Dir(f.Name)
+		if isInternal(dir) {
+			continue
+		}
+
+		rc, err := f.File.Open()
+		if err != nil {
+			return nil, err
+		}
+		src, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// Files that don't parse are skipped, the documentation of
+		// the package's other files is still useful.
+		file, err := parser.ParseFile(fset, f.Name, src, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+
+		if dirs[dir] == nil {
+			dirs[dir] = map[string]*ast.File{}
+		}
+		dirs[dir][f.Name] = file
+	}
+
+	var packages []Package
+	for dir, files := range dirs {
+		name := packageName(files)
+		if name == "main" {
+			continue
+		}
+
+		pkgFiles := map[string]*ast.File{}
+		for filename, f := range files {
+			if f.Name.Name == name {
+				pkgFiles[filename] = f
+			}
+		}
+
+		importPath := modulePath
+		if dir != "." {
+			importPath = modulePath + "/" + dir
+		}
+
+		p := doc.New(&ast.Package{Name: name, Files: pkgFiles}, importPath, 0)
+		packages = append(packages, Package{
+			ImportPath:   importPath,
+			Name:         p.Name,
+			Synopsis:     doc.Synopsis(p.Doc),
+			Doc:          p.Doc,
+			Declarations: declarations(fset, p),
+		})
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].ImportPath < packages[j].ImportPath
+	})
+
+	return packages, nil
+}
+
+// packageName returns the package name most files in a directory use,
+// as some files might be excluded by build constraints, like package main.
+func packageName(files map[string]*ast.File) string {
+	counts := map[string]int{}
+	for _, f := range files {
+		counts[f.Name.Name]++
+	}
+
+	var name string
+	for n, c := range counts {
+		if c > counts[name] || (c == counts[name] && n < name) {
+			name = n
+		}
+	}
+	return name
+}
+
+// declarations returns all exported declarations of a package's documentation
+func declarations(fset *token.FileSet, p *doc.Package) []Declaration {
+	var decls []Declaration
+	seen := map[string]bool{}
+
+	add := func(d Declaration) {
+		// Declarations can be duplicated by files for different platforms
+		if key := d.Kind + " " + d.Name; !seen[key] {
+			seen[key] = true
+			decls = append(decls, d)
+		}
+	}
+
+	values := func(kind string, values []*doc.Value) {
+		for _, v := range values {
+			for _, spec := range v.Decl.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				decl := *vs
+				decl.Doc, decl.Comment = nil, nil
+				for _, name := range vs.Names {
+					if !name.IsExported() {
+						continue
+					}
+					add(Declaration{
+						Kind: kind,
+						Name: name.Name,
+						Decl: kind + " " + printNode(fset, &decl),
+						Doc:  v.Doc,
+					})
+				}
+			}
+		}
+	}
+
+	funcs := func(funcs []*doc.Func) {
+		for _, f := range funcs {
+			decl := *f.Decl
+			decl.Doc, decl.Body = nil, nil
+
+			d := Declaration{Kind: KindFunc, Name: f.Name, Decl: printNode(fset, &decl), Doc: f.Doc}
+			if f.Recv != "" {
+				d.Kind = KindMethod
+				d.Name = strings.TrimPrefix(f.Recv, "*") + "." + f.Name
+			}
+			add(d)
+		}
+	}
+
+	values(KindConst, p.Consts)
+	values(KindVar, p.Vars)
+	funcs(p.Funcs)
+
+	for _, t := range p.Types {
+		for _, spec := range t.Decl.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok || ts.Name.Name != t.Name {
+				continue
+			}
+			decl := *ts
+			decl.Doc, decl.Comment = nil, nil
+			add(Declaration{
+				Kind: KindType,
+				Name: t.Name,
+				Decl: "type " + printNode(fset, &decl),
+				Doc:  t.Doc,
+			})
+		}
+		values(KindConst, t.Consts)
+		values(KindVar, t.Vars)
+		funcs(t.Funcs)
+		funcs(t.Methods)
+	}
+
+	return decls
+}
+
+func printNode(fset *token.FileSet, node interface{}) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// licenseFiles returns the names of all license files in a module's root
+func licenseFiles(files []moduleFile) []string {
+	var names []string
+	for _, f := range files {
+		if path.Dir(f.Name) != "." {
+			continue
+		}
+		base := strings.ToUpper(f.Name)
+		if strings.HasPrefix(base, "LICENSE") || strings.HasPrefix(base, "LICENCE") ||
+			strings.HasPrefix(base, "COPYING") || strings.HasPrefix(base, "UNLICENSE") {
+			names = append(names, f.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func testModuleZip(t *testing.T, prefix string, files map[string]string) *zip.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(prefix + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestParsePackages(t *testing.T) {
+	zr := testModuleZip(t, "github.com/foo/bar@v1.0.0/", map[string]string{
+		"go.mod":  "module github.com/foo/bar\n",
+		"LICENSE": "MIT License",
+		"bar.go": `// Package bar does things.
+package bar
+
+// Version of bar
+const Version = "1.0.0"
+
+// Bar does bar things.
+type Bar struct {
+	Name string
+	secret string
+}
+
+// New returns a Bar.
+func New(name string) *Bar { return &Bar{Name: name} }
+
+// Do does it.
+func (b *Bar) Do() error { return nil }
+
+func unexported() {}
+`,
+		"bar_test.go":           "package bar\n\nfunc TestBar() {}\n",
+		"baz/baz.go":            "// Package baz is nested.\npackage baz\n\nvar Baz = 1\n",
+		"internal/x/x.go":       "package x\n\nfunc X() {}\n",
+		"cmd/bar/main.go":       "package main\n\nfunc main() {}\n",
+		"nested/go.mod":         "module github.com/foo/bar/nested\n",
+		"nested/nested.go":      "package nested\n\nfunc Nested() {}\n",
+		"testdata/broken/a.go":  "package broken\n\nfunc Broken() {}\n",
+		"vendor/x.org/y/y.go":   "package y\n\nfunc Y() {}\n",
+		"baz/broken_syntax.go":  "package baz\n\nfunc {",
+		"baz/other_platform.go": "package baz\n\nvar Baz = 2\n",
+	})
+
+	files := moduleFiles(zr, "github.com/foo/bar", "v1.0.0")
+	if names := licenseFiles(files); len(names) != 1 || names[0] != "LICENSE" {
+		t.Errorf("expected LICENSE to be the only license file, got %v", names)
+	}
+
+	packages, err := parsePackages(files, "github.com/foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages, got %+v", packages)
+	}
+
+	bar := packages[0]
+	if bar.ImportPath != "github.com/foo/bar" || bar.Name != "bar" || bar.Synopsis != "Package bar does things." {
+		t.Errorf("unexpected package %s %s %q", bar.ImportPath, bar.Name, bar.Synopsis)
+	}
+
+	expected := []Declaration{
+		{Kind: KindConst, Name: "Version", Decl: `const Version = "1.0.0"`, Doc: "Version of bar\n"},
+		{Kind: KindType, Name: "Bar", Decl: "type Bar struct {\n\tName string\n\t// contains filtered or unexported fields\n}", Doc: "Bar does bar things.\n"},
+		{Kind: KindFunc, Name: "New", Decl: "func New(name string) *Bar", Doc: "New returns a Bar.\n"},
+		{Kind: KindMethod, Name: "Bar.Do", Decl: "func (b *Bar) Do() error", Doc: "Do does it.\n"},
+	}
+	if len(bar.Declarations) != len(expected) {
+		t.Fatalf("expected %d declarations, got %+v", len(expected), bar.Declarations)
+	}
+	for i, d := range expected {
+		if bar.Declarations[i] != d {
+			t.Errorf("expected declaration\n%+v\ngot\n%+v", d, bar.Declarations[i])
+		}
+	}
+
+	baz := packages[1]
+	if baz.ImportPath != "github.com/foo/bar/baz" || len(baz.Declarations) != 1 {
+		t.Errorf("unexpected package %s with declarations %+v", baz.ImportPath, baz.Declarations)
+	}
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of errors of the services godep requests, like GitHub and godoc.org.
+// They are the causes of an UpstreamError.
+var (
+	// ErrRateLimited is returned when a service limits the requests of godep
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnavailable is returned when a service fails or can't be reached
+	ErrUnavailable = errors.New("unavailable")
+	// ErrTimeout is returned when a service doesn't respond in time
+	ErrTimeout = errors.New("timed out")
+)
+
+// UpstreamError is an error of a service godep requests.
+// Its cause is the kind of the error, like ErrUnavailable.
+type UpstreamError struct {
+	Service string
+	Kind    error
+	Err     error
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Service, e.Kind, e.Err)
+}
+
+// Cause returns the kind of the error
+func (e *UpstreamError) Cause() error {
+	return e.Kind
+}
+
+// upstreamError classifies the error of a request to a service as timeout or unavailable,
+// if the request failed on the network. Other errors are returned as they are.
+func upstreamError(service string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	cause := errors.Cause(err)
+	if cause == context.DeadlineExceeded {
+		return &UpstreamError{Service: service, Kind: ErrTimeout, Err: err}
+	}
+	if ne, ok := cause.(net.Error); ok {
+		if ne.Timeout() {
+			return &UpstreamError{Service: service, Kind: ErrTimeout, Err: err}
+		}
+		return &UpstreamError{Service: service, Kind: ErrUnavailable, Err: err}
+	}
+	return err
+}
+
+// statusError returns the error of an unexpected status code of a service's response
+func statusError(service string, code int) error {
+	err := errors.Errorf("unexpected status code from %s: %d", service, code)
+	switch {
+	case code == http.StatusTooManyRequests:
+		return &UpstreamError{Service: service, Kind: ErrRateLimited, Err: err}
+	case code >= 500:
+		return &UpstreamError{Service: service, Kind: ErrUnavailable, Err: err}
+	}
+	return err
+}
+
+// githubError classifies the errors of GitHub's GraphQL API,
+// which only returns the messages of errors and the status codes of failed responses.
+func githubError(err error) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "rate limit"):
+		return &UpstreamError{Service: "GitHub", Kind: ErrRateLimited, Err: err}
+	case strings.Contains(msg, "non-200 OK status code: 5"):
+		return &UpstreamError{Service: "GitHub", Kind: ErrUnavailable, Err: err}
+	}
+	return upstreamError("GitHub", err)
+}
+
+// upstream returns the UpstreamError an error was caused by, if any
+func upstream(err error) (*UpstreamError, bool) {
+	for err != nil {
+		if ue, ok := err.(*UpstreamError); ok {
+			return ue, true
+		}
+		c, ok := err.(interface{ Cause() error })
+		if !ok {
+			return nil, false
+		}
+		err = c.Cause()
+	}
+	return nil, false
+}
+
+// errorStatus returns the http status code responded with for an error
+func errorStatus(err error) int {
+	switch errors.Cause(err) {
+	case ErrNotFound, ErrSubscriptionNotFound:
+		return http.StatusNotFound
+	case ErrRateLimited, ErrUnavailable:
+		return http.StatusBadGateway
+	case ErrTimeout:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorPage explains an error to users
+type ErrorPage struct {
+	Title   string
+	Heading string
+	Message string
+}
+
+// newErrorPage returns the page explaining an error, without exposing internal details
+func newErrorPage(err error) ErrorPage {
+	service := "a service godep depends on"
+	if ue, ok := upstream(err); ok {
+		service = ue.Service
+	}
+
+	switch errors.Cause(err) {
+	case ErrNotFound, ErrSubscriptionNotFound:
+		return ErrorPage{
+			Title:   "Not Found - ",
+			Heading: "Not Found",
+			Message: "Oh snap! Our team of gophers could not find the web page you are looking for.",
+		}
+	case ErrRateLimited:
+		return ErrorPage{
+			Title:   "Rate Limited - ",
+			Heading: "Rate Limited",
+			Message: "The requests godep can make to " + service + " are used up right now. Please try again in a few minutes.",
+		}
+	case ErrUnavailable:
+		return ErrorPage{
+			Title:   "Unavailable - ",
+			Heading: "Unavailable",
+			Message: "godep can't show this page, as " + service + " is unavailable right now. Please try again later.",
+		}
+	case ErrTimeout:
+		return ErrorPage{
+			Title:   "Timeout - ",
+			Heading: "Timeout",
+			Message: "godep can't show this page, as " + service + " took too long to respond. Please try again later.",
+		}
+	}
+	return ErrorPage{
+		Title:   "Error - ",
+		Heading: "Something went wrong",
+		Message: "Our team of gophers failed to render this page. Please try again later or open an issue if this keeps happening.",
+	}
+}
+
+// writeErrorPage responds with a html page explaining an error
+func writeErrorPage(w http.ResponseWriter, tmpl *template.Template, err error) {
+	w.WriteHeader(errorStatus(err))
+	tmpl.ExecuteTemplate(w, "layout", newErrorPage(err))
+}
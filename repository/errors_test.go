@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type timeoutError struct{ timeout bool }
+
+func (e timeoutError) Error() string   { return "network error" }
+func (e timeoutError) Timeout() bool   { return e.timeout }
+func (e timeoutError) Temporary() bool { return false }
+
+var _ net.Error = timeoutError{}
+
+func TestUpstreamError(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{err: errors.Wrap(context.DeadlineExceeded, "failed to request"), kind: ErrTimeout},
+		{err: timeoutError{timeout: true}, kind: ErrTimeout},
+		{err: timeoutError{timeout: false}, kind: ErrUnavailable},
+		{err: errors.New("failed to decode"), kind: nil},
+	}
+
+	for _, test := range tests {
+		err := upstreamError("godoc.org", test.err)
+		ue, ok := upstream(err)
+		if test.kind == nil {
+			if ok {
+				t.Errorf("expected %v not to be an upstream error", test.err)
+			}
+			continue
+		}
+		if !ok || ue.Kind != test.kind || ue.Service != "godoc.org" {
+			t.Errorf("expected %v to be %v, got %v", test.err, test.kind, err)
+		}
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		code   int
+		status int
+	}{
+		{code: http.StatusTooManyRequests, status: http.StatusBadGateway},
+		{code: http.StatusServiceUnavailable, status: http.StatusBadGateway},
+		{code: http.StatusInternalServerError, status: http.StatusBadGateway},
+		{code: http.StatusForbidden, status: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		if status := errorStatus(statusError("godoc.org", test.code)); status != test.status {
+			t.Errorf("expected status code %d for %d, got %d", test.status, test.code, status)
+		}
+	}
+}
+
+func TestGitHubError(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{err: errors.New("API rate limit exceeded for user ID 1."), kind: ErrRateLimited},
+		{err: errors.New("non-200 OK status code: 502 Bad Gateway body: \"\""), kind: ErrUnavailable},
+		{err: context.DeadlineExceeded, kind: ErrTimeout},
+	}
+
+	for _, test := range tests {
+		err := errors.Wrap(githubError(test.err), "failed to get repository")
+		if errors.Cause(err) != test.kind {
+			t.Errorf("expected %q to be %v, got %v", test.err, test.kind, errors.Cause(err))
+		}
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{err: ErrNotFound, status: http.StatusNotFound},
+		{err: ErrSubscriptionNotFound, status: http.StatusNotFound},
+		{err: statusError("GitHub", http.StatusTooManyRequests), status: http.StatusBadGateway},
+		{err: upstreamError("GitHub", context.DeadlineExceeded), status: http.StatusGatewayTimeout},
+		{err: errors.New("failed to query"), status: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		if status := errorStatus(test.err); status != test.status {
+			t.Errorf("expected status code %d for %v, got %d", test.status, test.err, status)
+		}
+	}
+}
+
+func TestNewErrorPage(t *testing.T) {
+	p := newErrorPage(errors.Wrap(statusError("the module proxy", http.StatusServiceUnavailable), "failed to get modules"))
+	if p.Heading != "Unavailable" || !strings.Contains(p.Message, "the module proxy is unavailable") {
+		t.Errorf("unexpected page for an unavailable service: %+v", p)
+	}
+
+	// Internal errors aren't shown to users
+	p = newErrorPage(errors.New("pq: connection refused"))
+	if strings.Contains(p.Message, "pq") {
+		t.Errorf("expected the page not to expose the error: %+v", p)
+	}
+}
+
+func TestKeepIncomplete(t *testing.T) {
+	old := Repository{
+		Statistics: []Statistic{{Name: "Stars", Value: 1}, {Name: "Imports", Value: 2}, {Name: "Importers", Value: 3}},
+		Modules:    []Module{{Path: "github.com/foo/bar", Major: 1}},
+	}
+	repo := Repository{
+		Statistics: []Statistic{{Name: "Stars", Value: 4}},
+		Incomplete: []string{sourceGoDoc, sourceProxy},
+	}
+
+	repo = keepIncomplete(old, repo)
+	if len(repo.Statistics) != 3 || repo.Statistics[0].Value != 4 || repo.Statistics[1].Value != 2 {
+		t.Errorf("expected the new stars and the old import statistics, got %+v", repo.Statistics)
+	}
+	if len(repo.Modules) != 1 {
+		t.Errorf("expected the old modules, got %+v", repo.Modules)
+	}
+}
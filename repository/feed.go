@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"encoding/xml"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+type (
+	// Feed is a list of recent entries, rendered as Atom or RSS feed.
+	// Links are paths relative to godep's url.
+	Feed struct {
+		Title       string
+		Link        string
+		Description string
+		Entries     []FeedEntry
+	}
+	// FeedEntry is a single item of a Feed
+	FeedEntry struct {
+		Title     string
+		Link      string
+		Content   string
+		Published time.Time
+	}
+	// Release is a Version of a Repository
+	Release struct {
+		URL string `json:"url"`
+		Version
+	}
+)
+
+// feedEntries is the number of entries in a feed
+const feedEntries = 50
+
+// Updated returns the time the newest entry was published
+func (f Feed) Updated() time.Time {
+	var updated time.Time
+	for _, e := range f.Entries {
+		if e.Published.After(updated) {
+			updated = e.Published
+		}
+	}
+	return updated
+}
+
+// releaseFeedEntries creates the entries of releases, the newest first.
+// Drafts and releases without a publishing date are left out.
+func releaseFeedEntries(releases []Release) []FeedEntry {
+	var entries []FeedEntry
+	for _, r := range releases {
+		if r.Draft || r.Published.IsZero() {
+			continue
+		}
+		e := FeedEntry{
+			Title:     r.URL + " " + r.Name,
+			Link:      "/" + r.URL + "/@" + r.Name,
+			Content:   r.Notes,
+			Published: r.Published,
+		}
+		if r.Prerelease {
+			e.Title += " (pre-release)"
+		}
+		entries = append(entries, e)
+	}
+	sortFeedEntries(entries)
+	return entries
+}
+
+// sortFeedEntries sorts entries by their publishing date, the newest first
+func sortFeedEntries(entries []FeedEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Published.After(entries[j].Published)
+	})
+}
+
+type (
+	atomFeed struct {
+		XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+		Title   string      `xml:"title"`
+		ID      string      `xml:"id"`
+		Link    []atomLink  `xml:"link"`
+		Updated string      `xml:"updated"`
+		Entries []atomEntry `xml:"entry"`
+	}
+	atomLink struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr,omitempty"`
+	}
+	atomEntry struct {
+		Title   string      `xml:"title"`
+		ID      string      `xml:"id"`
+		Link    atomLink    `xml:"link"`
+		Updated string      `xml:"updated"`
+		Content *atomText   `xml:"content,omitempty"`
+		Author  *atomAuthor `xml:"author,omitempty"`
+	}
+	atomText struct {
+		Type string `xml:"type,attr"`
+		Body string `xml:",chardata"`
+	}
+	atomAuthor struct {
+		Name string `xml:"name"`
+	}
+)
+
+// Atom renders the feed in the Atom format with links to the base url
+func (f Feed) Atom(base string) ([]byte, error) {
+	feed := atomFeed{
+		Title:   f.Title,
+		ID:      base + f.Link,
+		Link:    []atomLink{{Href: base + f.Link}},
+		Updated: f.Updated().UTC().Format(time.RFC3339),
+	}
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			Title:   e.Title,
+			ID:      base + e.Link,
+			Link:    atomLink{Href: base + e.Link},
+			Updated: e.Published.UTC().Format(time.RFC3339),
+			Author:  &atomAuthor{Name: "godep.org"},
+		}
+		if e.Content != "" {
+			entry.Content = &atomText{Type: "text", Body: e.Content}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	return append([]byte(xml.Header), data...), err
+}
+
+type (
+	rssFeed struct {
+		XMLName xml.Name   `xml:"rss"`
+		Version string     `xml:"version,attr"`
+		Channel rssChannel `xml:"channel"`
+	}
+	rssChannel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+		Items         []rssItem `xml:"item"`
+	}
+	rssItem struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		GUID        string `xml:"guid"`
+		Description string `xml:"description,omitempty"`
+		PubDate     string `xml:"pubDate"`
+	}
+)
+
+// RSS renders the feed in the RSS 2.0 format with links to the base url
+func (f Feed) RSS(base string) ([]byte, error) {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        base + f.Link,
+			Description: f.Description,
+		},
+	}
+	if updated := f.Updated(); !updated.IsZero() {
+		feed.Channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, e := range f.Entries {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        base + e.Link,
+			GUID:        base + e.Link,
+			Description: e.Content,
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	return append([]byte(xml.Header), data...), err
+}
+
+// writeFeed responds with a feed in the requested format, either atom or rss,
+// linking to godep's public base url
+func writeFeed(w http.ResponseWriter, feed Feed, format, base string) {
+	var data []byte
+	var err error
+	switch format {
+	case "atom":
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		data, err = feed.Atom(base)
+	case "rss":
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		data, err = feed.RSS(base)
+	default:
+		http.Error(w, "feeds are available as atom or rss", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(data)
+}
+
+// ReleaseFeedHandler responds with a feed of a repository's releases
+func ReleaseFeedHandler(repositories Service, errorTmpl *template.Template, base string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uri, err := githubURL(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		feed, err := repositories.ReleaseFeed(r.Context(), uri)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		writeFeed(w, feed, chi.URLParam(r, "format"), base)
+	}
+}
+
+// IndexFeedHandler responds with a feed of newly indexed repositories and new releases
+func IndexFeedHandler(repositories Service, base string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feed, err := repositories.IndexFeed(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeFeed(w, feed, chi.URLParam(r, "format"), base)
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func testFeed() Feed {
+	published := time.Date(2018, 2, 1, 12, 0, 0, 0, time.UTC)
+	return Feed{
+		Title: "github.com/foo/bar releases",
+		Link:  "/github.com/foo/bar",
+		Entries: releaseFeedEntries([]Release{
+			{URL: "github.com/foo/bar", Version: Version{Name: "v1.0.0", Published: published.Add(-24 * time.Hour)}},
+			{URL: "github.com/foo/bar", Version: Version{Name: "v1.1.0", Published: published, Notes: "Fixes <everything>"}},
+			{URL: "github.com/foo/bar", Version: Version{Name: "v1.2.0-rc1", Published: published.Add(-time.Hour), Prerelease: true}},
+			{URL: "github.com/foo/bar", Version: Version{Name: "v1.2.0", Draft: true}},
+		}),
+	}
+}
+
+func TestReleaseFeedEntries(t *testing.T) {
+	expected := []string{
+		"github.com/foo/bar v1.1.0",
+		"github.com/foo/bar v1.2.0-rc1 (pre-release)",
+		"github.com/foo/bar v1.0.0",
+	}
+
+	entries := testFeed().Entries
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %+v", len(expected), entries)
+	}
+	for i, title := range expected {
+		if entries[i].Title != title {
+			t.Errorf("expected entry %d to be %s, got %s", i, title, entries[i].Title)
+		}
+	}
+}
+
+func TestFeedAtom(t *testing.T) {
+	data, err := testFeed().Atom("https://godep.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("failed to parse atom feed: %v\n%s", err, data)
+	}
+	if feed.Updated != "2018-02-01T12:00:00Z" {
+		t.Errorf("expected the feed to be updated at the newest entry, got %s", feed.Updated)
+	}
+	if len(feed.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(feed.Entries))
+	}
+	if e := feed.Entries[0]; e.Link.Href != "https://godep.org/github.com/foo/bar/@v1.1.0" || e.Content == nil || e.Content.Body != "Fixes <everything>" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+func TestFeedRSS(t *testing.T) {
+	data, err := testFeed().RSS("https://godep.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var feed rssFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("failed to parse rss feed: %v\n%s", err, data)
+	}
+	if feed.Version != "2.0" || feed.Channel.Link != "https://godep.org/github.com/foo/bar" {
+		t.Errorf("unexpected channel %+v", feed.Channel)
+	}
+	if len(feed.Channel.Items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(feed.Channel.Items))
+	}
+	if pub := feed.Channel.Items[0].PubDate; pub != "Thu, 01 Feb 2018 12:00:00 +0000" {
+		t.Errorf("unexpected publishing date %s", pub)
+	}
+}
@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/go-kit/kit/metrics"
+	"github.com/pkg/errors"
 	"github.com/shurcooL/githubql"
 	"golang.org/x/oauth2"
 )
@@ -19,13 +21,18 @@ type (
 	}
 )
 
-// NewGitHubClient initializes a new GitHub client from a token
-func NewGitHubClient(token string, apiCalls metrics.Histogram) (*GitHub, error) {
+// NewGitHubClient initializes a new GitHub client from a token,
+// which authenticates the requests of a http client
+func NewGitHubClient(token string, client *http.Client, apiCalls metrics.Histogram) (*GitHub, error) {
+	authenticated := oauth2.NewClient(
+		context.WithValue(context.Background(), oauth2.HTTPClient, client),
+		oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
+	)
+	// oauth2 only uses the client's transport
+	authenticated.Timeout = client.Timeout
+
 	gh := &GitHub{
-		client: githubql.NewClient(oauth2.NewClient(
-			context.TODO(),
-			oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
-		)),
+		client:   githubql.NewClient(authenticated),
 		apiCalls: apiCalls.With("service", "github"),
 	}
 
@@ -35,6 +42,12 @@ func NewGitHubClient(token string, apiCalls metrics.Histogram) (*GitHub, error)
 	return gh, nil
 }
 
+// missingRepository returns if a query failed as the repository doesn't exist or isn't accessible.
+// GitHub responds to these queries with a NOT_FOUND error, whose message is the only part returned.
+func missingRepository(err error) bool {
+	return strings.Contains(err.Error(), "Could not resolve to a Repository")
+}
+
 // Get a repository's data from its urlPath
 func (gh *GitHub) Get(ctx context.Context, urlPath string) (Repository, error) {
 	defer func(start time.Time) {
@@ -47,11 +60,15 @@ func (gh *GitHub) Get(ctx context.Context, urlPath string) (Repository, error) {
 	var q struct {
 		Repository struct {
 			Description      githubql.String
+			PushedAt         githubql.DateTime
+			IsArchived       githubql.Boolean
 			Forks            struct{ TotalCount githubql.Int }
 			Stargazers       struct{ TotalCount githubql.Int }
 			Watchers         struct{ TotalCount githubql.Int }
 			Issues           struct{ TotalCount githubql.Int }
+			OpenIssues       struct{ TotalCount githubql.Int } `graphql:"openIssues: issues(states: OPEN)"`
 			PullRequests     struct{ TotalCount githubql.Int }
+			OpenPullRequests struct{ TotalCount githubql.Int } `graphql:"openPullRequests: pullRequests(states: OPEN)"`
 			RepositoryTopics struct {
 				Edges []struct {
 					Node struct {
@@ -63,29 +80,10 @@ func (gh *GitHub) Get(ctx context.Context, urlPath string) (Repository, error) {
 				}
 			} `graphql:"repositoryTopics(first: 100)"`
 			LicenseInfo struct {
+				Name   githubql.String
 				SpdxID githubql.String
 				URL    githubql.URI
 			}
-			Releases struct {
-				Edges []struct {
-					Node struct {
-						IsDraft      githubql.Boolean
-						IsPrerelease githubql.Boolean
-						PublishedAt  githubql.DateTime
-						URL          githubql.URI
-						Tag          struct {
-							Name githubql.String
-						}
-					}
-				}
-			} `graphql:"releases(last:100)"`
-			Refs struct {
-				Edges []struct {
-					Node struct {
-						Name githubql.String
-					}
-				}
-			} `graphql:"refs(refPrefix: \"refs/tags/\", first: 100)"`
 		} `graphql:"repository(owner: $owner, name: $name)"`
 	}
 
@@ -95,13 +93,22 @@ func (gh *GitHub) Get(ctx context.Context, urlPath string) (Repository, error) {
 	}
 
 	if err := gh.client.Query(ctx, &q, vars); err != nil {
-		return Repository{}, err
+		if missingRepository(err) {
+			return Repository{}, ErrNotFound
+		}
+		return Repository{}, githubError(err)
 	}
 
 	repo := Repository{
 		URL:         urlPath,
 		Description: string(q.Repository.Description),
 		Updated:     time.Now(),
+		Pushed:      q.Repository.PushedAt.Time,
+		Archived:    bool(q.Repository.IsArchived),
+		License: License{
+			Name: string(q.Repository.LicenseInfo.Name),
+			SPDX: string(q.Repository.LicenseInfo.SpdxID),
+		},
 		Statistics: []Statistic{{
 			Name:  "Forks",
 			Value: int(q.Repository.Forks.TotalCount),
@@ -110,6 +117,14 @@ func (gh *GitHub) Get(ctx context.Context, urlPath string) (Repository, error) {
 			Name:  "Issues",
 			Value: int(q.Repository.Issues.TotalCount),
 			URL:   fmt.Sprintf("https://github.com/%s/%s/issues", owner, name),
+		}, {
+			Name:  "OpenIssues",
+			Value: int(q.Repository.OpenIssues.TotalCount),
+			URL:   fmt.Sprintf("https://github.com/%s/%s/issues?q=is%%3Aopen", owner, name),
+		}, {
+			Name:  "OpenPullRequests",
+			Value: int(q.Repository.OpenPullRequests.TotalCount),
+			URL:   fmt.Sprintf("https://github.com/%s/%s/pulls?q=is%%3Aopen", owner, name),
 		}, {
 			Name:  "PullRequests",
 			Value: int(q.Repository.PullRequests.TotalCount),
@@ -125,20 +140,144 @@ func (gh *GitHub) Get(ctx context.Context, urlPath string) (Repository, error) {
 		}},
 	}
 
-	if len(q.Repository.Releases.Edges) > 0 {
+	if u := q.Repository.LicenseInfo.URL.URL; u != nil {
+		repo.License.URL = u.String()
+	}
+
+	releases, err := gh.releases(ctx, owner, name)
+	if err != nil {
+		return repo, err
+	}
+	tags, err := gh.tags(ctx, owner, name)
+	if err != nil {
+		return repo, err
+	}
+	repo.Versions = mergeReleases(tags, releases)
+
+	return repo, nil
+}
+
+// mergeReleases returns all tags of a repository, as the module proxy knows them all,
+// with the data of their releases. Releases whose tag doesn't exist yet, like drafts, are kept too.
+func mergeReleases(tags, releases []Version) []Version {
+	byTag := make(map[string]Version, len(releases))
+	for _, r := range releases {
+		byTag[r.Name] = r
+	}
+
+	versions := make([]Version, 0, len(tags)+len(releases))
+	tagged := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		if r, ok := byTag[t.Name]; ok {
+			t = r
+		}
+		tagged[t.Name] = true
+		versions = append(versions, t)
+	}
+	for _, r := range releases {
+		if !tagged[r.Name] {
+			versions = append(versions, r)
+		}
+	}
+
+	return versions
+}
+
+// githubPageInfo is used to paginate through GitHub's connections
+type githubPageInfo struct {
+	EndCursor   githubql.String
+	HasNextPage githubql.Boolean
+}
+
+// releases returns all releases of a repository by following the cursor
+// until there are no pages left. The oldest release is returned first.
+func (gh *GitHub) releases(ctx context.Context, owner, name string) ([]Version, error) {
+	var q struct {
+		Repository struct {
+			Releases struct {
+				PageInfo githubPageInfo
+				Edges    []struct {
+					Node struct {
+						Description  githubql.String
+						IsDraft      githubql.Boolean
+						IsPrerelease githubql.Boolean
+						PublishedAt  githubql.DateTime
+						URL          githubql.URI
+						Tag          struct {
+							Name githubql.String
+						}
+					}
+				}
+			} `graphql:"releases(first: 100, after: $cursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	vars := map[string]interface{}{
+		"owner":  githubql.String(owner),
+		"name":   githubql.String(name),
+		"cursor": (*githubql.String)(nil),
+	}
+
+	var versions []Version
+	for {
+		if err := gh.client.Query(ctx, &q, vars); err != nil {
+			return versions, errors.Wrap(githubError(err), "failed to query releases")
+		}
+
 		for _, r := range q.Repository.Releases.Edges {
-			repo.Versions = append(repo.Versions, Version{
-				Name:      string(r.Node.Tag.Name),
-				Published: r.Node.PublishedAt.Time,
+			versions = append(versions, Version{
+				Name:       string(r.Node.Tag.Name),
+				Published:  r.Node.PublishedAt.Time,
+				Prerelease: bool(r.Node.IsPrerelease),
+				Draft:      bool(r.Node.IsDraft),
+				Notes:      string(r.Node.Description),
 			})
 		}
-	} else {
+
+		if !q.Repository.Releases.PageInfo.HasNextPage {
+			return versions, nil
+		}
+		vars["cursor"] = githubql.NewString(q.Repository.Releases.PageInfo.EndCursor)
+	}
+}
+
+// tags returns all tags of a repository by following the cursor
+// until there are no pages left.
+func (gh *GitHub) tags(ctx context.Context, owner, name string) ([]Version, error) {
+	var q struct {
+		Repository struct {
+			Refs struct {
+				PageInfo githubPageInfo
+				Edges    []struct {
+					Node struct {
+						Name githubql.String
+					}
+				}
+			} `graphql:"refs(refPrefix: \"refs/tags/\", first: 100, after: $cursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+	}
+
+	vars := map[string]interface{}{
+		"owner":  githubql.String(owner),
+		"name":   githubql.String(name),
+		"cursor": (*githubql.String)(nil),
+	}
+
+	var versions []Version
+	for {
+		if err := gh.client.Query(ctx, &q, vars); err != nil {
+			return versions, errors.Wrap(githubError(err), "failed to query tags")
+		}
+
 		for _, r := range q.Repository.Refs.Edges {
-			repo.Versions = append(repo.Versions, Version{
+			versions = append(versions, Version{
 				Name: string(r.Node.Name),
 			})
 		}
-	}
 
-	return repo, nil
+		if !q.Repository.Refs.PageInfo.HasNextPage {
+			return versions, nil
+		}
+		vars["cursor"] = githubql.NewString(q.Repository.Refs.PageInfo.EndCursor)
+	}
 }
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeReleases(t *testing.T) {
+	published := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	tags := []Version{{Name: "v1.0.0"}, {Name: "v1.1.0"}, {Name: "v1.2.0"}}
+	releases := []Version{
+		{Name: "v1.1.0", Published: published, Notes: "Faster"},
+		{Name: "v2.0.0", Draft: true},
+	}
+
+	expected := []Version{
+		{Name: "v1.0.0"},
+		{Name: "v1.1.0", Published: published, Notes: "Faster"},
+		{Name: "v1.2.0"},
+		{Name: "v2.0.0", Draft: true},
+	}
+	if versions := mergeReleases(tags, releases); !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expected %+v, got %+v", expected, versions)
+	}
+}
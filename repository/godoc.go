@@ -28,11 +28,9 @@ type GoDoc struct {
 }
 
 // NewGoDoc initializes GoDoc with a http client
-func NewGoDoc(apiCalls metrics.Histogram) (*GoDoc, error) {
+func NewGoDoc(client *http.Client, apiCalls metrics.Histogram) (*GoDoc, error) {
 	gd := &GoDoc{
-		client: &http.Client{
-			Timeout: 5 * time.Second,
-		},
+		client:   client,
 		apiCalls: apiCalls.With("service", "godoc"),
 	}
 
@@ -59,14 +57,19 @@ func (gd *GoDoc) Get(ctx context.Context, urlPath string) (*GoDocInfo, error) {
 		return nil, errors.Wrap(err, "failed to create request")
 	}
 
-	resp, err := gd.client.Do(req)
+	resp, err := gd.client.Do(req.WithContext(ctx))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to do the request")
+		return nil, upstreamError("godoc.org", errors.Wrap(err, "failed to do the request"))
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
 		return nil, ErrNotFound
 	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, statusError("godoc.org", resp.StatusCode)
+	}
 
 	doc, err := goquery.NewDocumentFromResponse(resp)
 	if err != nil {
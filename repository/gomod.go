@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// GoMod is the parsed content of a go.mod file.
+// Deprecated is the deprecation message of the module, if it's deprecated.
+// Replace and Exclude only apply if the module is the main module of a build.
+type GoMod struct {
+	Module     string
+	Deprecated string
+	Require    []Dependency
+	Retract    []Retraction
+	Replace    []Replacement
+	Exclude    []Dependency
+}
+
+// Replacement replaces a module with another module or a local directory.
+// Without an old version all versions of the module are replaced,
+// without a new version the new path is a local directory.
+type Replacement struct {
+	Old Dependency
+	New Dependency
+}
+
+// Retraction is a range of versions retracted by a module's author
+type Retraction struct {
+	Low       string `json:"low"`
+	High      string `json:"high"`
+	Rationale string `json:"rationale"`
+}
+
+// goModLine is a single directive of a go.mod file,
+// directives inside of blocks are returned with the block's verb.
+// Blank lines are returned as separators without a verb.
+type goModLine struct {
+	Verb    string
+	Args    []string
+	Comment string
+	Blank   bool
+}
+
+// parseGoMod parses the parts of a go.mod file godep is interested in
+func parseGoMod(data []byte) (GoMod, error) {
+	var mod GoMod
+
+	lines, err := parseGoModLines(data)
+	if err != nil {
+		return mod, err
+	}
+
+	// comments preceding a directive, they document it
+	var comments []string
+	for _, l := range lines {
+		if l.Blank {
+			// only a contiguous block of comments documents a directive
+			comments = nil
+			continue
+		}
+		if len(l.Args) == 0 {
+			comments = append(comments, l.Comment)
+			continue
+		}
+		doc := append(comments, l.Comment)
+		comments = nil
+
+		switch l.Verb {
+		case "module":
+			if len(l.Args) != 1 {
+				return mod, errors.New("invalid module directive")
+			}
+			mod.Module = l.Args[0]
+			mod.Deprecated = deprecation(doc)
+		case "require":
+			if len(l.Args) != 2 {
+				return mod, errors.New("invalid require directive")
+			}
+			mod.Require = append(mod.Require, Dependency{
+				Path:     l.Args[0],
+				Version:  l.Args[1],
+				Indirect: l.Comment == "indirect" || strings.HasPrefix(l.Comment, "indirect;"),
+			})
+		case "retract":
+			r, err := parseRetraction(l.Args)
+			if err != nil {
+				return mod, err
+			}
+			r.Rationale = strings.TrimSpace(strings.Join(doc, " "))
+			mod.Retract = append(mod.Retract, r)
+		case "replace":
+			r, err := parseReplacement(l.Args)
+			if err != nil {
+				return mod, err
+			}
+			mod.Replace = append(mod.Replace, r)
+		case "exclude":
+			if len(l.Args) != 2 {
+				return mod, errors.New("invalid exclude directive")
+			}
+			mod.Exclude = append(mod.Exclude, Dependency{Path: l.Args[0], Version: l.Args[1]})
+		}
+	}
+
+	if mod.Module == "" {
+		return mod, errors.New("go.mod has no module directive")
+	}
+
+	return mod, nil
+}
+
+// deprecation returns the message of a "Deprecated:" paragraph in a module's comments
+func deprecation(comments []string) string {
+	for i, c := range comments {
+		if strings.HasPrefix(c, "Deprecated:") {
+			msg := []string{strings.TrimSpace(strings.TrimPrefix(c, "Deprecated:"))}
+			for _, c := range comments[i+1:] {
+				if c == "" {
+					break
+				}
+				msg = append(msg, c)
+			}
+			return strings.TrimSpace(strings.Join(msg, " "))
+		}
+	}
+	return ""
+}
+
+// parseRetraction parses the arguments of a retract directive,
+// either a single version or a closed interval like [v1.0.0, v1.9.9].
+func parseRetraction(args []string) (Retraction, error) {
+	arg := strings.Join(args, " ")
+	if !strings.HasPrefix(arg, "[") {
+		if len(args) != 1 {
+			return Retraction{}, errors.New("invalid retract directive")
+		}
+		return Retraction{Low: args[0], High: args[0]}, nil
+	}
+
+	if !strings.HasSuffix(arg, "]") {
+		return Retraction{}, errors.New("invalid retract interval")
+	}
+	bounds := strings.Split(strings.Trim(arg, "[]"), ",")
+	if len(bounds) != 2 {
+		return Retraction{}, errors.New("invalid retract interval")
+	}
+	r := Retraction{Low: strings.TrimSpace(bounds[0]), High: strings.TrimSpace(bounds[1])}
+	if r.Low == "" || r.High == "" {
+		return Retraction{}, errors.New("invalid retract interval")
+	}
+	return r, nil
+}
+
+// parseReplacement parses the arguments of a replace directive like
+// old [version] => new [version]
+func parseReplacement(args []string) (Replacement, error) {
+	arrow := -1
+	for i, a := range args {
+		if a == "=>" {
+			arrow = i
+		}
+	}
+	if arrow < 1 {
+		return Replacement{}, errors.New("invalid replace directive")
+	}
+	from, to := args[:arrow], args[arrow+1:]
+	if len(from) > 2 || len(to) < 1 || len(to) > 2 {
+		return Replacement{}, errors.New("invalid replace directive")
+	}
+
+	r := Replacement{Old: Dependency{Path: from[0]}, New: Dependency{Path: to[0]}}
+	if len(from) == 2 {
+		r.Old.Version = from[1]
+	}
+	if len(to) == 2 {
+		r.New.Version = to[1]
+	}
+	return r, nil
+}
+
+// formatModule formats a module and its version, if any, like go list -m does
+func formatModule(d Dependency) string {
+	if d.Version == "" {
+		return d.Path
+	}
+	return d.Path + " " + d.Version
+}
+
+// replacement returns the module replacing a module version, if it's replaced.
+// Replacements of the exact version take precedence over ones of all versions.
+func (mod GoMod) replacement(path, version string) (Dependency, bool) {
+	var all *Dependency
+	for i, r := range mod.Replace {
+		if r.Old.Path != path {
+			continue
+		}
+		if r.Old.Version == version {
+			return r.New, true
+		}
+		if r.Old.Version == "" {
+			all = &mod.Replace[i].New
+		}
+	}
+	if all != nil {
+		return *all, true
+	}
+	return Dependency{}, false
+}
+
+// excludes returns true if a module version is excluded
+func (mod GoMod) excludes(path, version string) bool {
+	for _, e := range mod.Exclude {
+		if e.Path == path && e.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// retracts returns true if a version is within the retracted range
+func (r Retraction) retracts(version string) bool {
+	return !versionLess(version, r.Low) && !versionLess(r.High, version)
+}
+
+// retractVersions marks the versions retracted by the go.mod of their major version's module
+func retractVersions(versions []Version, retractions map[int][]Retraction) {
+	for i, v := range versions {
+		sv, ok := parseSemver(v.Name)
+		if !ok {
+			continue
+		}
+		for _, r := range retractions[sv.major] {
+			if r.retracts(v.Name) {
+				versions[i].Retracted = true
+				versions[i].Retraction = r.Rationale
+				break
+			}
+		}
+	}
+}
+
+func parseGoModLines(data []byte) ([]goModLine, error) {
+	var lines []goModLine
+	var block string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		text, comment, commented := scanner.Text(), "", false
+		if i := strings.Index(text, "//"); i >= 0 {
+			text, comment, commented = text[:i], strings.TrimSpace(text[i+2:]), true
+		}
+
+		fields, err := goModFields(text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "go.mod line %d", n)
+		}
+
+		switch {
+		case len(fields) == 0 && !commented:
+			lines = append(lines, goModLine{Blank: true})
+		case len(fields) == 0:
+			// Comments on their own line are kept as they may carry meaning,
+			// e.g. the deprecation of a module.
+			lines = append(lines, goModLine{Verb: block, Comment: comment})
+		case block != "" && fields[0] == ")":
+			block = ""
+		case block != "":
+			lines = append(lines, goModLine{Verb: block, Args: fields, Comment: comment})
+		case len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+		default:
+			lines = append(lines, goModLine{Verb: fields[0], Args: fields[1:], Comment: comment})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if block != "" {
+		return nil, errors.Errorf("go.mod has an unclosed %s block", block)
+	}
+
+	return lines, nil
+}
+
+// goModFields splits a line into its fields and unquotes quoted ones
+func goModFields(line string) ([]string, error) {
+	var fields []string
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		switch line[0] {
+		case '"', '`':
+			end := 1
+			for ; end < len(line) && line[end] != line[0]; end++ {
+				if line[0] == '"' && line[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(line) {
+				return nil, errors.New("unterminated quoted string")
+			}
+			f, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, f)
+			line = line[end+1:]
+		default:
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			fields = append(fields, line[:end])
+			line = line[end:]
+		}
+	}
+	return fields, nil
+}
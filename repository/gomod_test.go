@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGoMod(t *testing.T) {
+	mods := map[string]GoMod{
+		"module github.com/foo/bar\n\nrequire github.com/pkg/errors v0.8.0\nrequire (\n\t// errors\n\tgolang.org/x/sync v0.1.0 // indirect\n)\n": {
+			Module: "github.com/foo/bar",
+			Require: []Dependency{
+				{Path: "github.com/pkg/errors", Version: "v0.8.0"},
+				{Path: "golang.org/x/sync", Version: "v0.1.0", Indirect: true},
+			},
+		},
+		"module github.com/foo/bar\n": {
+			Module: "github.com/foo/bar",
+		},
+		"// comment\nmodule \"github.com/foo/bar/v2\" // trailing\n\ngo 1.12\n": {
+			Module: "github.com/foo/bar/v2",
+		},
+		"module github.com/foo/bar/v3\n\nrequire (\n\tgithub.com/pkg/errors v0.8.0\n)\n": {
+			Module:  "github.com/foo/bar/v3",
+			Require: []Dependency{{Path: "github.com/pkg/errors", Version: "v0.8.0"}},
+		},
+	}
+
+	for data, expected := range mods {
+		mod, err := parseGoMod([]byte(data))
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", data, err)
+			continue
+		}
+		if mod.Module != expected.Module {
+			t.Errorf("expected module %s, got %s", expected.Module, mod.Module)
+		}
+		if len(mod.Require) != len(expected.Require) {
+			t.Errorf("expected %d requirements, got %d", len(expected.Require), len(mod.Require))
+			continue
+		}
+		for i, r := range expected.Require {
+			if mod.Require[i] != r {
+				t.Errorf("expected requirement %+v, got %+v", r, mod.Require[i])
+			}
+		}
+	}
+
+	invalid := []string{
+		"go 1.12\n",
+		"module\n",
+		"module \"github.com/foo/bar\n",
+		"module github.com/foo/bar\nrequire (\n",
+		"module github.com/foo/bar\nrequire github.com/pkg/errors\n",
+	}
+	for _, data := range invalid {
+		if _, err := parseGoMod([]byte(data)); err == nil {
+			t.Errorf("expected parsing %q to fail", data)
+		}
+	}
+}
+
+func TestParseGoModReplaceExclude(t *testing.T) {
+	data := `module github.com/foo/bar
+
+replace (
+	github.com/pkg/errors => github.com/fork/errors v0.9.0
+	golang.org/x/sync v0.1.0 => ../sync
+)
+replace golang.org/x/net v0.1.0 => golang.org/x/net v0.2.0
+
+exclude github.com/foo/baz v1.1.0
+`
+	mod, err := parseGoMod([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replace := []Replacement{
+		{Old: Dependency{Path: "github.com/pkg/errors"}, New: Dependency{Path: "github.com/fork/errors", Version: "v0.9.0"}},
+		{Old: Dependency{Path: "golang.org/x/sync", Version: "v0.1.0"}, New: Dependency{Path: "../sync"}},
+		{Old: Dependency{Path: "golang.org/x/net", Version: "v0.1.0"}, New: Dependency{Path: "golang.org/x/net", Version: "v0.2.0"}},
+	}
+	if !reflect.DeepEqual(mod.Replace, replace) {
+		t.Errorf("expected replacements %+v, got %+v", replace, mod.Replace)
+	}
+	if !mod.excludes("github.com/foo/baz", "v1.1.0") || mod.excludes("github.com/foo/baz", "v1.2.0") {
+		t.Errorf("expected only github.com/foo/baz v1.1.0 to be excluded, got %+v", mod.Exclude)
+	}
+
+	invalid := []string{
+		"module github.com/foo/bar\nreplace github.com/pkg/errors\n",
+		"module github.com/foo/bar\nreplace => github.com/pkg/errors v0.8.0\n",
+		"module github.com/foo/bar\nexclude github.com/pkg/errors\n",
+	}
+	for _, data := range invalid {
+		if _, err := parseGoMod([]byte(data)); err == nil {
+			t.Errorf("expected parsing %q to fail", data)
+		}
+	}
+}
+
+func TestParseGoModDeprecation(t *testing.T) {
+	mods := map[string]string{
+		"// Deprecated: use github.com/foo/baz instead.\nmodule github.com/foo/bar\n":                             "use github.com/foo/baz instead.",
+		"// Package bar does things.\n//\n// Deprecated: unmaintained,\n// use baz.\nmodule github.com/foo/bar\n": "unmaintained, use baz.",
+		"module github.com/foo/bar // Deprecated: unmaintained\n":                                                 "unmaintained",
+		"// Deprecated: not the module's\nrequire github.com/pkg/errors v0.8.0\nmodule github.com/foo/bar\n":      "",
+		"// comment\nmodule github.com/foo/bar\n":                                                                 "",
+	}
+
+	for data, expected := range mods {
+		mod, err := parseGoMod([]byte(data))
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", data, err)
+			continue
+		}
+		if mod.Deprecated != expected {
+			t.Errorf("expected deprecation %q of %q, got %q", expected, data, mod.Deprecated)
+		}
+	}
+}
+
+func TestParseGoModRetract(t *testing.T) {
+	data := "module github.com/foo/bar\n\n// Published too early.\nretract v1.0.0\n\nretract (\n\t[v1.1.0, v1.2.0] // Broken builds.\n\tv0.1.0\n)\n"
+
+	mod, err := parseGoMod([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Retraction{
+		{Low: "v1.0.0", High: "v1.0.0", Rationale: "Published too early."},
+		{Low: "v1.1.0", High: "v1.2.0", Rationale: "Broken builds."},
+		{Low: "v0.1.0", High: "v0.1.0"},
+	}
+	if len(mod.Retract) != len(expected) {
+		t.Fatalf("expected %d retractions, got %+v", len(expected), mod.Retract)
+	}
+	for i, r := range expected {
+		if mod.Retract[i] != r {
+			t.Errorf("expected retraction %+v, got %+v", r, mod.Retract[i])
+		}
+	}
+
+	invalid := []string{
+		"module github.com/foo/bar\nretract [v1.0.0]\n",
+		"module github.com/foo/bar\nretract [v1.0.0, v1.1.0\n",
+		"module github.com/foo/bar\nretract v1.0.0 v1.1.0\n",
+	}
+	for _, data := range invalid {
+		if _, err := parseGoMod([]byte(data)); err == nil {
+			t.Errorf("expected parsing %q to fail", data)
+		}
+	}
+}
+
+func TestParseGoModComments(t *testing.T) {
+	data := "// Copyright 2018 The Authors.\n\nmodule github.com/foo/bar\n\n// Retractions\n\n// Published too early.\nretract v1.0.0\n"
+
+	mod, err := parseGoMod([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mod.Deprecated != "" {
+		t.Errorf("expected no deprecation, got %q", mod.Deprecated)
+	}
+	expected := Retraction{Low: "v1.0.0", High: "v1.0.0", Rationale: "Published too early."}
+	if len(mod.Retract) != 1 || mod.Retract[0] != expected {
+		t.Errorf("expected retraction %+v, got %+v", expected, mod.Retract)
+	}
+}
+
+func TestRetractVersions(t *testing.T) {
+	versions := []Version{
+		{Name: "v2.0.1"},
+		{Name: "v2.0.0"},
+		{Name: "v1.2.0"},
+		{Name: "v1.1.1"},
+		{Name: "v1.0.0"},
+		{Name: "weekly"},
+	}
+	retractions := map[int][]Retraction{
+		1: {{Low: "v1.1.0", High: "v1.2.0", Rationale: "Broken builds."}},
+		2: {{Low: "v2.0.0", High: "v2.0.0"}},
+	}
+
+	retractVersions(versions, retractions)
+
+	expected := map[string]string{"v2.0.0": "", "v1.2.0": "Broken builds.", "v1.1.1": "Broken builds."}
+	for _, v := range versions {
+		rationale, retracted := expected[v.Name]
+		if v.Retracted != retracted || v.Retraction != rationale {
+			t.Errorf("expected %s to be retracted %t with %q, got %t with %q", v.Name, retracted, rationale, v.Retracted, v.Retraction)
+		}
+	}
+}
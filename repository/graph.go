@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strconv"
+)
+
+type (
+	// Graph is the transitive dependency graph of a module version
+	Graph struct {
+		Path    string      `json:"path"`
+		Version string      `json:"version"`
+		Depth   int         `json:"depth"`
+		Nodes   []GraphNode `json:"nodes"`
+		Edges   []GraphEdge `json:"edges"`
+	}
+	// GraphNode is a module version in a Graph. Duplicate is true if
+	// the graph contains the same module at different versions.
+	GraphNode struct {
+		ID        string `json:"id"`
+		Path      string `json:"path"`
+		Version   string `json:"version"`
+		Depth     int    `json:"depth"`
+		Duplicate bool   `json:"duplicate"`
+	}
+	// GraphEdge is a requirement of one GraphNode on another
+	GraphEdge struct {
+		From     string `json:"from"`
+		To       string `json:"to"`
+		Indirect bool   `json:"indirect"`
+	}
+	// DependencyEdge is a Dependency of a module version found at
+	// a depth while walking the dependencies transitively.
+	DependencyEdge struct {
+		Module     string
+		Version    string
+		Dependency Dependency
+		Depth      int
+	}
+)
+
+// Depths of the dependency graph
+const (
+	defaultGraphDepth = 3
+	maxGraphDepth     = 10
+)
+
+func graphID(path, version string) string {
+	return path + "@" + version
+}
+
+// newGraph builds the Graph of a module version from its transitive dependencies
+func newGraph(path, version string, depth int, deps []DependencyEdge) Graph {
+	g := Graph{Path: path, Version: version, Depth: depth}
+
+	nodes := map[string]*GraphNode{}
+	addNode := func(path, version string, depth int) string {
+		id := graphID(path, version)
+		if n, ok := nodes[id]; ok {
+			if depth < n.Depth {
+				n.Depth = depth
+			}
+			return id
+		}
+		nodes[id] = &GraphNode{ID: id, Path: path, Version: version, Depth: depth}
+		return id
+	}
+
+	addNode(path, version, 0)
+	edges := map[GraphEdge]bool{}
+	for _, d := range deps {
+		from := addNode(d.Module, d.Version, d.Depth-1)
+		to := addNode(d.Dependency.Path, d.Dependency.Version, d.Depth)
+		edges[GraphEdge{From: from, To: to, Indirect: d.Dependency.Indirect}] = true
+	}
+
+	versions := map[string]int{}
+	for _, n := range nodes {
+		versions[n.Path]++
+	}
+	for _, n := range nodes {
+		n.Duplicate = versions[n.Path] > 1
+		g.Nodes = append(g.Nodes, *n)
+	}
+	for e := range edges {
+		g.Edges = append(g.Edges, e)
+	}
+
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		if g.Nodes[i].Depth != g.Nodes[j].Depth {
+			return g.Nodes[i].Depth < g.Nodes[j].Depth
+		}
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+
+	return g
+}
+
+// dependencyEdges walks the requirements of a module's go.mod transitively up to a depth,
+// like the edges of go mod graph. Each module version is walked once at the lowest depth
+// it's found at. The module's replace and exclude directives are applied like by buildList.
+func dependencyEdges(mod GoMod, version string, depth int, requirements func(path, version string) ([]Dependency, error)) ([]DependencyEdge, error) {
+	var edges []DependencyEdge
+	add := func(module, version string, reqs []Dependency, d int) {
+		for _, r := range reqs {
+			if r.Path == mod.Module || mod.excludes(r.Path, r.Version) {
+				continue
+			}
+			edges = append(edges, DependencyEdge{Module: module, Version: version, Dependency: r, Depth: d})
+		}
+	}
+
+	add(mod.Module, version, mod.Require, 1)
+	visited := map[string]bool{}
+	for start := 0; start < len(edges); start++ {
+		e := edges[start]
+		if e.Depth >= depth || visited[graphID(e.Dependency.Path, e.Dependency.Version)] {
+			continue
+		}
+		visited[graphID(e.Dependency.Path, e.Dependency.Version)] = true
+
+		r := e.Dependency
+		if replacement, ok := mod.replacement(r.Path, r.Version); ok {
+			if replacement.Version == "" {
+				continue
+			}
+			r = replacement
+		}
+		reqs, err := requirements(r.Path, r.Version)
+		if err != nil {
+			return nil, err
+		}
+		add(e.Dependency.Path, e.Dependency.Version, reqs, e.Depth+1)
+	}
+
+	return edges, nil
+}
+
+// DOT returns the Graph in the graphviz dot language
+func (g Graph) DOT() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "digraph %s {\n", strconv.Quote(graphID(g.Path, g.Version)))
+	buf.WriteString("\trankdir=LR;\n")
+	buf.WriteString("\tnode [shape=box, fontname=\"Helvetica\"];\n")
+
+	for _, n := range g.Nodes {
+		attrs := fmt.Sprintf("label=%s", strconv.Quote(n.Path+"\n"+n.Version))
+		if n.Duplicate {
+			attrs += ", style=filled, fillcolor=\"#f5e0e0\""
+		}
+		fmt.Fprintf(&buf, "\t%s [%s];\n", strconv.Quote(n.ID), attrs)
+	}
+	for _, e := range g.Edges {
+		attrs := ""
+		if e.Indirect {
+			attrs = " [style=dashed]"
+		}
+		fmt.Fprintf(&buf, "\t%s -> %s%s;\n", strconv.Quote(e.From), strconv.Quote(e.To), attrs)
+	}
+
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// Dimensions of the rendered SVG
+const (
+	svgPadding    = 20
+	svgNodeHeight = 34
+	svgRowHeight  = 46
+	svgColumnGap  = 60
+	svgCharWidth  = 7
+)
+
+var svgTmpl = template.Must(template.New("graph").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{ .Width }}" height="{{ .Height }}" viewBox="0 0 {{ .Width }} {{ .Height }}" font-family="Helvetica, Arial, sans-serif" font-size="12">
+<g fill="none" stroke="#999">
+{{- range .Edges }}
+<path d="M{{ .X1 }},{{ .Y1 }} C{{ .CX }},{{ .Y1 }} {{ .CX }},{{ .Y2 }} {{ .X2 }},{{ .Y2 }}"{{ if .Indirect }} stroke-dasharray="4,3"{{ end }}/>
+{{- end }}
+</g>
+{{- range .Nodes }}
+<g>
+<title>{{ .ID }}</title>
+<rect x="{{ .X }}" y="{{ .Y }}" width="{{ .Width }}" height="{{ .Height }}" rx="4" fill="{{ if .Duplicate }}#f5e0e0{{ else }}#e0ebf5{{ end }}" stroke="{{ if .Duplicate }}#a94442{{ else }}#375eab{{ end }}"/>
+<text x="{{ .TextX }}" y="{{ .PathY }}">{{ .Path }}</text>
+<text x="{{ .TextX }}" y="{{ .VersionY }}" fill="#5e5e5e">{{ .Version }}</text>
+</g>
+{{- end }}
+</svg>
+`))
+
+// SVG renders the Graph with a column for every depth of the dependencies
+func (g Graph) SVG() ([]byte, error) {
+	type svgNode struct {
+		GraphNode
+		X, Y, Width, Height    int
+		TextX, PathY, VersionY int
+	}
+	type svgEdge struct {
+		X1, Y1, X2, Y2, CX int
+		Indirect           bool
+	}
+
+	var columns [][]GraphNode
+	for _, n := range g.Nodes {
+		for len(columns) <= n.Depth {
+			columns = append(columns, nil)
+		}
+		columns[n.Depth] = append(columns[n.Depth], n)
+	}
+
+	nodes := map[string]*svgNode{}
+	var ordered []*svgNode
+	x, width, height := svgPadding, 0, 0
+	for _, column := range columns {
+		columnWidth := 0
+		for _, n := range column {
+			if w := len(n.Path) * svgCharWidth; w > columnWidth {
+				columnWidth = w
+			}
+		}
+		columnWidth += 2 * 8
+
+		for i, n := range column {
+			sn := &svgNode{
+				GraphNode: n,
+				X:         x,
+				Y:         svgPadding + i*svgRowHeight,
+				Width:     columnWidth,
+				Height:    svgNodeHeight,
+			}
+			sn.TextX = sn.X + 8
+			sn.PathY = sn.Y + 14
+			sn.VersionY = sn.Y + 28
+			nodes[n.ID] = sn
+			ordered = append(ordered, sn)
+
+			if h := sn.Y + sn.Height + svgPadding; h > height {
+				height = h
+			}
+		}
+
+		x += columnWidth + svgColumnGap
+		width = x - svgColumnGap + svgPadding
+	}
+
+	var edges []svgEdge
+	for _, e := range g.Edges {
+		from, to := nodes[e.From], nodes[e.To]
+		if from == nil || to == nil {
+			continue
+		}
+		x1, x2 := from.X+from.Width, to.X
+		edges = append(edges, svgEdge{
+			X1:       x1,
+			Y1:       from.Y + from.Height/2,
+			X2:       x2,
+			Y2:       to.Y + to.Height/2,
+			CX:       (x1 + x2) / 2,
+			Indirect: e.Indirect,
+		})
+	}
+
+	var buf bytes.Buffer
+	err := svgTmpl.Execute(&buf, struct {
+		Width, Height int
+		Nodes         []*svgNode
+		Edges         []svgEdge
+	}{Width: width, Height: height, Nodes: ordered, Edges: edges})
+
+	return buf.Bytes(), err
+}
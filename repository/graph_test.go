@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func testGraph() Graph {
+	return newGraph("github.com/foo/bar", "v1.0.0", 3, []DependencyEdge{
+		{Module: "github.com/foo/bar", Version: "v1.0.0", Dependency: Dependency{Path: "github.com/pkg/errors", Version: "v0.8.0"}, Depth: 1},
+		{Module: "github.com/foo/bar", Version: "v1.0.0", Dependency: Dependency{Path: "github.com/go-kit/kit", Version: "v0.6.0"}, Depth: 1},
+		{Module: "github.com/go-kit/kit", Version: "v0.6.0", Dependency: Dependency{Path: "github.com/pkg/errors", Version: "v0.8.1"}, Depth: 2},
+		{Module: "github.com/go-kit/kit", Version: "v0.6.0", Dependency: Dependency{Path: "github.com/go-logfmt/logfmt", Version: "v0.3.0", Indirect: true}, Depth: 2},
+	})
+}
+
+func TestNewGraph(t *testing.T) {
+	g := testGraph()
+
+	expected := []GraphNode{
+		{ID: "github.com/foo/bar@v1.0.0", Path: "github.com/foo/bar", Version: "v1.0.0", Depth: 0},
+		{ID: "github.com/go-kit/kit@v0.6.0", Path: "github.com/go-kit/kit", Version: "v0.6.0", Depth: 1},
+		{ID: "github.com/pkg/errors@v0.8.0", Path: "github.com/pkg/errors", Version: "v0.8.0", Depth: 1, Duplicate: true},
+		{ID: "github.com/go-logfmt/logfmt@v0.3.0", Path: "github.com/go-logfmt/logfmt", Version: "v0.3.0", Depth: 2},
+		{ID: "github.com/pkg/errors@v0.8.1", Path: "github.com/pkg/errors", Version: "v0.8.1", Depth: 2, Duplicate: true},
+	}
+	if len(g.Nodes) != len(expected) {
+		t.Fatalf("expected %d nodes, got %+v", len(expected), g.Nodes)
+	}
+	for i, n := range expected {
+		if g.Nodes[i] != n {
+			t.Errorf("expected node %+v, got %+v", n, g.Nodes[i])
+		}
+	}
+	if len(g.Edges) != 4 {
+		t.Errorf("expected 4 edges, got %+v", g.Edges)
+	}
+}
+
+func TestDependencyEdges(t *testing.T) {
+	gomods := map[string]string{
+		"github.com/go-kit/kit@v0.6.0":       "module github.com/go-kit/kit\n\nrequire (\n\tgithub.com/go-logfmt/logfmt v0.3.0 // indirect\n\tgithub.com/pkg/errors v0.8.1\n)\n",
+		"github.com/go-logfmt/logfmt@v0.3.0": "module github.com/go-logfmt/logfmt\n\nrequire github.com/kr/logfmt v0.1.0\n",
+		"github.com/kr/logfmt@v0.1.0":        "module github.com/kr/logfmt\n\nrequire github.com/kr/text v0.1.0\n",
+		"github.com/pkg/errors@v0.8.0":       "module github.com/pkg/errors\n",
+		"github.com/pkg/errors@v0.8.1":       "module github.com/pkg/errors\n",
+	}
+	requested := map[string]int{}
+	requirements := func(path, version string) ([]Dependency, error) {
+		requested[graphID(path, version)]++
+		mod, err := parseGoMod([]byte(gomods[graphID(path, version)]))
+		return mod.Require, err
+	}
+
+	mod := GoMod{Module: "github.com/foo/bar", Require: []Dependency{
+		{Path: "github.com/go-kit/kit", Version: "v0.6.0"},
+		{Path: "github.com/pkg/errors", Version: "v0.8.0"},
+	}}
+	edges, err := dependencyEdges(mod, "v1.0.0", 3, requirements)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := newGraph("github.com/foo/bar", "v1.0.0", 3, edges)
+	expected := []GraphNode{
+		{ID: "github.com/foo/bar@v1.0.0", Path: "github.com/foo/bar", Version: "v1.0.0", Depth: 0},
+		{ID: "github.com/go-kit/kit@v0.6.0", Path: "github.com/go-kit/kit", Version: "v0.6.0", Depth: 1},
+		{ID: "github.com/pkg/errors@v0.8.0", Path: "github.com/pkg/errors", Version: "v0.8.0", Depth: 1, Duplicate: true},
+		{ID: "github.com/go-logfmt/logfmt@v0.3.0", Path: "github.com/go-logfmt/logfmt", Version: "v0.3.0", Depth: 2},
+		{ID: "github.com/pkg/errors@v0.8.1", Path: "github.com/pkg/errors", Version: "v0.8.1", Depth: 2, Duplicate: true},
+		{ID: "github.com/kr/logfmt@v0.1.0", Path: "github.com/kr/logfmt", Version: "v0.1.0", Depth: 3},
+	}
+	if len(g.Nodes) != len(expected) {
+		t.Fatalf("expected %d nodes, got %+v", len(expected), g.Nodes)
+	}
+	for i, n := range expected {
+		if g.Nodes[i] != n {
+			t.Errorf("expected node %+v, got %+v", n, g.Nodes[i])
+		}
+	}
+	if len(g.Edges) != 5 {
+		t.Errorf("expected 5 edges, got %+v", g.Edges)
+	}
+	// Module versions at the maximum depth aren't walked
+	if requested["github.com/kr/logfmt@v0.1.0"] != 0 || requested["github.com/go-kit/kit@v0.6.0"] != 1 {
+		t.Errorf("expected only module versions above the depth to be walked once, got %v", requested)
+	}
+}
+
+func TestGraphDOT(t *testing.T) {
+	dot := testGraph().DOT()
+
+	for _, line := range []string{
+		`digraph "github.com/foo/bar@v1.0.0" {`,
+		`"github.com/pkg/errors@v0.8.1" [label="github.com/pkg/errors\nv0.8.1", style=filled, fillcolor="#f5e0e0"];`,
+		`"github.com/go-kit/kit@v0.6.0" -> "github.com/go-logfmt/logfmt@v0.3.0" [style=dashed];`,
+		`"github.com/foo/bar@v1.0.0" -> "github.com/go-kit/kit@v0.6.0";`,
+	} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("expected dot to contain %s, got:\n%s", line, dot)
+		}
+	}
+}
+
+func TestGraphSVG(t *testing.T) {
+	svg, err := testGraph().SVG()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName xml.Name
+		Groups  []struct {
+			Title string `xml:"title"`
+			Paths []struct {
+				D string `xml:"d,attr"`
+			} `xml:"path"`
+		} `xml:"g"`
+	}
+	if err := xml.Unmarshal(svg, &doc); err != nil {
+		t.Fatalf("failed to parse svg: %v\n%s", err, svg)
+	}
+	if doc.XMLName.Local != "svg" {
+		t.Errorf("expected svg root element, got %s", doc.XMLName.Local)
+	}
+	// The first group contains all edges, followed by a group per node
+	if len(doc.Groups) != 6 {
+		t.Fatalf("expected 6 groups, got %d", len(doc.Groups))
+	}
+	if len(doc.Groups[0].Paths) != 4 {
+		t.Errorf("expected 4 edges, got %d", len(doc.Groups[0].Paths))
+	}
+	if doc.Groups[1].Title != "github.com/foo/bar@v1.0.0" {
+		t.Errorf("expected the root node first, got %s", doc.Groups[1].Title)
+	}
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type (
+	// Health is a score from 0 to 100 estimating how well a Repository is maintained
+	Health struct {
+		Score      int               `json:"score"`
+		Computed   time.Time         `json:"computed"`
+		Components []HealthComponent `json:"components"`
+	}
+	// HealthComponent is a single aspect of a Repository's Health.
+	// Its score from 0 to 1 is weighted against the other components.
+	HealthComponent struct {
+		Name        string  `json:"name"`
+		Score       float64 `json:"score"`
+		Weight      int     `json:"weight"`
+		Explanation string  `json:"explanation"`
+	}
+	// ReleaseActivity summarizes when all versions of a Repository were published,
+	// as only its latest versions are loaded with it.
+	ReleaseActivity struct {
+		Latest   time.Time
+		LastYear int
+	}
+)
+
+// healthTTL is the duration after which the health of a repository is computed again
+const healthTTL = 24 * time.Hour
+
+const day = 24 * time.Hour
+
+// computeHealth computes the health of a repository, its release activity and the module version
+// of its current version. Components that can't be judged yet, like tests of a version that
+// wasn't analyzed, are left out.
+func computeHealth(repo Repository, releases ReleaseActivity, mv ModuleVersion, now time.Time) Health {
+	var components []HealthComponent
+
+	components = append(components, releaseHealth(releases, now))
+
+	if !repo.Pushed.IsZero() {
+		age := now.Sub(repo.Pushed)
+		components = append(components, HealthComponent{
+			Name:        "Last commit",
+			Score:       ageScore(age, 30*day, 90*day, 180*day, 365*day),
+			Weight:      20,
+			Explanation: fmt.Sprintf("The last commit was pushed %s.", daysAgo(age)),
+		})
+	}
+
+	components = append(components, issueHealth(repo.Statistics))
+
+	goMod := HealthComponent{Name: "go.mod", Weight: 15, Explanation: "The repository has no go.mod file."}
+	for _, m := range repo.Modules {
+		if m.Path != "" {
+			goMod.Score = 1
+			goMod.Explanation = "The repository is a Go module."
+		}
+	}
+	components = append(components, goMod)
+
+	if mv.Path != "" {
+		tests := HealthComponent{Name: "Tests", Weight: 10, Explanation: "The current version has no tests."}
+		if mv.Tests {
+			tests.Score = 1
+			tests.Explanation = "The current version has tests."
+		}
+		components = append(components, tests)
+	}
+
+	license := HealthComponent{Name: "License", Weight: 10, Explanation: "No license was found."}
+	if expr := licenseExpression(mv.Licenses); expr != "" {
+		license.Score = 1
+		license.Explanation = fmt.Sprintf("The current version is licensed under %s.", expr)
+	} else if repo.License.SPDX != "" && repo.License.SPDX != "NOASSERTION" {
+		license.Score = 1
+		license.Explanation = fmt.Sprintf("The repository is licensed under %s.", repo.License.SPDX)
+	}
+	components = append(components, license)
+
+	components = append(components, vulnerabilityHealth(repo.Vulnerabilities, mv.Path))
+
+	h := Health{Computed: now, Components: components}
+	var score float64
+	var weights int
+	for _, c := range components {
+		score += c.Score * float64(c.Weight)
+		weights += c.Weight
+	}
+	if weights > 0 {
+		h.Score = int(math.Round(100 * score / float64(weights)))
+	}
+
+	return h
+}
+
+// releaseHealth scores how recently and often a repository was released within the last year
+func releaseHealth(releases ReleaseActivity, now time.Time) HealthComponent {
+	c := HealthComponent{Name: "Releases", Weight: 20, Explanation: "The repository has no releases."}
+	if releases.Latest.IsZero() {
+		return c
+	}
+
+	age := now.Sub(releases.Latest)
+	c.Score = (ageScore(age, 90*day, 180*day, 365*day, 730*day) + math.Min(float64(releases.LastYear)/4, 1)) / 2
+	c.Explanation = fmt.Sprintf("%d releases within the last year, the latest was published %s.", releases.LastYear, daysAgo(age))
+	return c
+}
+
+// issueHealth scores the share of issues and pull requests that are closed
+func issueHealth(stats []Statistic) HealthComponent {
+	c := HealthComponent{Name: "Issues", Score: 1, Weight: 15, Explanation: "The repository has no issues or pull requests."}
+
+	values := map[string]int{}
+	for _, s := range stats {
+		values[s.Name] = s.Value
+	}
+
+	total := values["Issues"] + values["PullRequests"]
+	open := values["OpenIssues"] + values["OpenPullRequests"]
+	if total == 0 {
+		return c
+	}
+
+	c.Score = 1 - float64(open)/float64(total)
+	c.Explanation = fmt.Sprintf("%d of %d issues and pull requests are open.", open, total)
+	return c
+}
+
+// vulnerabilityHealth scores the known vulnerabilities of a module and its dependencies
+func vulnerabilityHealth(vulns []Vulnerability, path string) HealthComponent {
+	c := HealthComponent{Name: "Vulnerabilities", Score: 1, Weight: 10, Explanation: "No known vulnerabilities."}
+	if len(vulns) == 0 {
+		return c
+	}
+
+	c.Score = 0.5
+	c.Explanation = fmt.Sprintf("%d known vulnerabilities in dependencies.", len(vulns))
+	for _, v := range vulns {
+		if v.Module == path {
+			c.Score = 0
+			c.Explanation = fmt.Sprintf("%d known vulnerabilities, affecting the current version itself.", len(vulns))
+		}
+	}
+	return c
+}
+
+// ageScore scores an age by the thresholds it's below, from 1 for the first to 0 above the last
+func ageScore(age time.Duration, thresholds ...time.Duration) float64 {
+	for i, t := range thresholds {
+		if age <= t {
+			return 1 - float64(i)/float64(len(thresholds))
+		}
+	}
+	return 0
+}
+
+// daysAgo describes a duration in days
+func daysAgo(d time.Duration) string {
+	switch days := int(d / day); days {
+	case 0:
+		return "today"
+	case 1:
+		return "yesterday"
+	default:
+		return fmt.Sprintf("%d days ago", days)
+	}
+}
+
+// hasTests returns true if a module contains any test files
+func hasTests(files []moduleFile) bool {
+	for _, f := range files {
+		if strings.HasSuffix(f.Name, "_test.go") {
+			return true
+		}
+	}
+	return false
+}
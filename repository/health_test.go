@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeHealth(t *testing.T) {
+	now := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := Repository{
+		Pushed: now.Add(-10 * day),
+		Statistics: []Statistic{
+			{Name: "Issues", Value: 80},
+			{Name: "OpenIssues", Value: 10},
+			{Name: "PullRequests", Value: 20},
+			{Name: "OpenPullRequests", Value: 10},
+		},
+		Modules: []Module{{Major: 1, Path: "github.com/foo/bar"}},
+		License: License{SPDX: "MIT"},
+	}
+	mv := ModuleVersion{Path: "github.com/foo/bar", Version: "v1.2.0", Tests: true}
+
+	releases := ReleaseActivity{Latest: now.Add(-30 * day), LastYear: 2}
+
+	h := computeHealth(repo, releases, mv, now)
+
+	expected := map[string]float64{
+		"Releases":        0.75,
+		"Last commit":     1,
+		"Issues":          0.8,
+		"go.mod":          1,
+		"Tests":           1,
+		"License":         1,
+		"Vulnerabilities": 1,
+	}
+	if len(h.Components) != len(expected) {
+		t.Fatalf("expected %d components, got %+v", len(expected), h.Components)
+	}
+	for _, c := range h.Components {
+		if c.Score != expected[c.Name] {
+			t.Errorf("expected %s to score %v, got %v: %s", c.Name, expected[c.Name], c.Score, c.Explanation)
+		}
+	}
+	// (20*0.75 + 20 + 15*0.8 + 15 + 10 + 10 + 10) / 100
+	if h.Score != 92 {
+		t.Errorf("expected a score of 92, got %d", h.Score)
+	}
+}
+
+func TestComputeHealthUnanalyzed(t *testing.T) {
+	now := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := Repository{
+		Vulnerabilities: []Vulnerability{{ID: "GO-2018-0001", Module: "github.com/foo/baz"}},
+	}
+
+	h := computeHealth(repo, ReleaseActivity{}, ModuleVersion{}, now)
+
+	for _, c := range h.Components {
+		switch c.Name {
+		case "Tests", "Last commit":
+			t.Errorf("expected %s not to be judged", c.Name)
+		case "Vulnerabilities":
+			if c.Score != 0.5 {
+				t.Errorf("expected vulnerable dependencies to score 0.5, got %v", c.Score)
+			}
+		}
+	}
+	// Only issues and vulnerabilities of dependencies count: (15 + 10*0.5) / 70
+	if h.Score != 29 {
+		t.Errorf("expected a score of 29, got %d", h.Score)
+	}
+}
+
+func TestReleaseHealth(t *testing.T) {
+	now := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		releases    ReleaseActivity
+		score       float64
+		explanation string
+	}{
+		{releases: ReleaseActivity{}, score: 0, explanation: "The repository has no releases."},
+		{releases: ReleaseActivity{Latest: now.Add(-200 * day)}, score: 0.25, explanation: "0 releases within the last year, the latest was published 200 days ago."},
+		// All releases are counted, not only the latest versions loaded with the repository
+		{releases: ReleaseActivity{Latest: now.Add(-day), LastYear: 40}, score: 1, explanation: "40 releases within the last year, the latest was published yesterday."},
+	}
+
+	for _, tt := range tests {
+		c := releaseHealth(tt.releases, now)
+		if c.Score != tt.score || c.Explanation != tt.explanation {
+			t.Errorf("expected %+v to score %v with %q, got %v with %q", tt.releases, tt.score, tt.explanation, c.Score, c.Explanation)
+		}
+	}
+}
+
+func TestAgeScore(t *testing.T) {
+	tests := []struct {
+		age   time.Duration
+		score float64
+	}{
+		{age: 0, score: 1},
+		{age: 30 * day, score: 1},
+		{age: 31 * day, score: 0.75},
+		{age: 365 * day, score: 0.25},
+		{age: 366 * day, score: 0},
+	}
+
+	for _, tt := range tests {
+		if score := ageScore(tt.age, 30*day, 90*day, 180*day, 365*day); score != tt.score {
+			t.Errorf("expected ageScore(%v) to be %v, got %v", tt.age, tt.score, score)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Homepage contains urls of repositories with different categories.
+// Sections that couldn't be retrieved are marked as failed, the others are still shown.
+type Homepage struct {
+	Popular []string
+	Healthy []string
+	Latest  []string
+	Random  []string
+	Failed  map[string]bool
+}
+
+// Sections of the homepage
+const (
+	sectionPopular = "popular"
+	sectionHealthy = "healthy"
+	sectionLatest  = "latest"
+	sectionRandom  = "random"
+)
+
+const (
+	// homepageLimit is the number of repositories in each section of the homepage
+	homepageLimit = 15
+	// homepageTimeout is the time all sections of the homepage need to be retrieved in
+	homepageTimeout = 2 * time.Second
+)
+
+// homepageSection is a section of the homepage, which is cached for its ttl
+type homepageSection struct {
+	name string
+	ttl  time.Duration
+	get  func(ctx context.Context, limit int) ([]string, error)
+	urls *[]string
+}
+
+// homepageCache caches the sections of the homepage independently,
+// as some change a lot more often than others.
+type homepageCache struct {
+	mu       sync.Mutex
+	sections map[string]cachedSection
+}
+
+type cachedSection struct {
+	urls    []string
+	expires time.Time
+}
+
+func newHomepageCache() *homepageCache {
+	return &homepageCache{sections: map[string]cachedSection{}}
+}
+
+// get returns the cached urls of a section and if they haven't expired yet
+func (c *homepageCache) get(name string, now time.Time) ([]string, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.sections[name]
+	return s.urls, ok, ok && now.Before(s.expires)
+}
+
+func (c *homepageCache) set(name string, urls []string, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sections[name] = cachedSection{urls: urls, expires: expires}
+}
+
+func (s *service) Homepage(ctx context.Context) (Homepage, error) {
+	h := Homepage{}
+	sections := []homepageSection{
+		{name: sectionPopular, ttl: 10 * time.Minute, get: s.repositories.GetPopular, urls: &h.Popular},
+		{name: sectionHealthy, ttl: 10 * time.Minute, get: s.repositories.GetHealthiest, urls: &h.Healthy},
+		{name: sectionLatest, ttl: time.Minute, get: s.repositories.GetLatest, urls: &h.Latest},
+		{name: sectionRandom, ttl: 30 * time.Second, get: s.repositories.GetRandom, urls: &h.Random},
+	}
+
+	h.Failed = loadHomepage(ctx, s.homepage, sections, time.Now())
+	if len(h.Failed) == len(sections) {
+		return h, errors.New("failed to retrieve any section of the homepage")
+	}
+	return h, nil
+}
+
+// loadHomepage retrieves the sections of the homepage concurrently with a shared deadline.
+// Sections that fail are taken from the cache, even if they expired,
+// and are returned as failed if they weren't cached before.
+func loadHomepage(ctx context.Context, cache *homepageCache, sections []homepageSection, now time.Time) map[string]bool {
+	ctx, cancel := context.WithTimeout(ctx, homepageTimeout)
+	defer cancel()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed = map[string]bool{}
+	)
+	for _, section := range sections {
+		cached, ok, fresh := cache.get(section.name, now)
+		if fresh {
+			*section.urls = cached
+			continue
+		}
+
+		wg.Add(1)
+		go func(section homepageSection, cached []string, ok bool) {
+			defer wg.Done()
+
+			// Queries canceled by the deadline return an error,
+			// so only complete sections are cached.
+			urls, err := section.get(ctx, homepageLimit)
+			if err == nil {
+				cache.set(section.name, urls, now.Add(section.ttl))
+				*section.urls = urls
+				return
+			}
+			if ok {
+				*section.urls = cached
+				return
+			}
+
+			mu.Lock()
+			failed[section.name] = true
+			mu.Unlock()
+		}(section, cached, ok)
+	}
+	wg.Wait()
+
+	return failed
+}
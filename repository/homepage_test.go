@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadHomepage(t *testing.T) {
+	now := time.Now()
+	cache := newHomepageCache()
+	cache.set(sectionLatest, []string{"github.com/cached/latest"}, now.Add(-time.Second))
+	cache.set(sectionRandom, []string{"github.com/cached/random"}, now.Add(time.Minute))
+
+	fail := func(ctx context.Context, limit int) ([]string, error) {
+		return nil, errors.New("failed to query")
+	}
+	calls := 0
+	succeed := func(ctx context.Context, limit int) ([]string, error) {
+		calls++
+		return []string{"github.com/foo/bar"}, nil
+	}
+
+	var h Homepage
+	sections := []homepageSection{
+		{name: sectionPopular, ttl: time.Minute, get: succeed, urls: &h.Popular},
+		{name: sectionHealthy, ttl: time.Minute, get: fail, urls: &h.Healthy},
+		{name: sectionLatest, ttl: time.Minute, get: fail, urls: &h.Latest},
+		{name: sectionRandom, ttl: time.Minute, get: succeed, urls: &h.Random},
+	}
+
+	failed := loadHomepage(context.Background(), cache, sections, now)
+	if !reflect.DeepEqual(failed, map[string]bool{sectionHealthy: true}) {
+		t.Errorf("expected only the healthy section to fail, got %v", failed)
+	}
+	if !reflect.DeepEqual(h.Popular, []string{"github.com/foo/bar"}) {
+		t.Errorf("expected the retrieved popular section, got %v", h.Popular)
+	}
+	// Expired sections are shown, if they can't be retrieved again
+	if !reflect.DeepEqual(h.Latest, []string{"github.com/cached/latest"}) {
+		t.Errorf("expected the expired latest section, got %v", h.Latest)
+	}
+	if !reflect.DeepEqual(h.Random, []string{"github.com/cached/random"}) || calls != 1 {
+		t.Errorf("expected the cached random section without retrieving it, got %v after %d calls", h.Random, calls)
+	}
+
+	if urls, _, fresh := cache.get(sectionPopular, now); !fresh || len(urls) != 1 {
+		t.Errorf("expected the popular section to be cached, got %v", urls)
+	}
+	if urls, ok, _ := cache.get(sectionHealthy, now); ok {
+		t.Errorf("expected the failed healthy section not to be cached, got %v", urls)
+	}
+}
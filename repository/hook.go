@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxHookPayload is the size of the largest webhook payload accepted, GitHub caps payloads at 25MB
+const maxHookPayload = 25 << 20
+
+// githubHookEvent is the part of GitHub's webhook payloads needed to refresh a repository
+type githubHookEvent struct {
+	Action     string `json:"action"`
+	Ref        string `json:"ref"`
+	RefType    string `json:"ref_type"`
+	Repository struct {
+		FullName      string `json:"full_name"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+}
+
+// validGitHubSignature checks the X-Hub-Signature-256 header of a payload,
+// which is the hex encoded HMAC-SHA256 of the payload using the webhook's secret.
+func validGitHubSignature(payload []byte, signature, secret string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// refreshesRepository returns if a GitHub event changes the data godep has of a repository:
+// published releases, created tags, pushes of tags or to the default branch
+// and changes of the repository itself, like it being archived.
+func (e githubHookEvent) refreshesRepository(event string) bool {
+	if e.Repository.FullName == "" {
+		return false
+	}
+
+	switch event {
+	case "release":
+		return true
+	case "create":
+		return e.RefType == "tag"
+	case "push":
+		return strings.HasPrefix(e.Ref, "refs/tags/") || e.Ref == "refs/heads/"+e.Repository.DefaultBranch
+	case "repository":
+		switch e.Action {
+		case "archived", "unarchived", "edited", "publicized":
+			return true
+		}
+	}
+	return false
+}
+
+// GitHubHookHandler receives GitHub's webhooks and queues the repositories to be refreshed.
+// Payloads need to be signed with the webhook's secret. The secret is shared by all repositories,
+// so events only refresh repositories that are indexed already, each at most as often as the limiter allows.
+func GitHubHookHandler(repositories Service, queue *RefreshQueue, limiter *RateLimiter, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxHookPayload))
+		if err != nil {
+			http.Error(w, "failed to read payload", http.StatusBadRequest)
+			return
+		}
+
+		if !validGitHubSignature(payload, r.Header.Get("X-Hub-Signature-256"), secret) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		event := r.Header.Get("X-GitHub-Event")
+		if event == "ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var e githubHookEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			http.Error(w, "failed to decode payload", http.StatusBadRequest)
+			return
+		}
+
+		if !e.refreshesRepository(event) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		uri := "github.com/" + e.Repository.FullName
+		indexed, err := repositories.Indexed(r.Context(), uri)
+		if err != nil {
+			http.Error(w, "failed to look up repository", http.StatusInternalServerError)
+			return
+		}
+		if !indexed {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// Events for a repository that is queued already don't count against its limit
+		if s, ok := queue.Status(uri); !ok || s.State != RefreshQueued {
+			if ok, wait := limiter.Allow(uri, time.Now()); !ok {
+				tooManyRequests(w, wait)
+				return
+			}
+		}
+
+		if !queue.Enqueue(uri) {
+			http.Error(w, "too many repositories queued to be refreshed", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidGitHubSignature(t *testing.T) {
+	// Example of GitHub's documentation on validating webhook deliveries
+	payload := []byte("Hello, World!")
+	signature := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	if !validGitHubSignature(payload, signature, "It's a Secret to Everybody") {
+		t.Error("expected the signature to be valid")
+	}
+	if validGitHubSignature(payload, signature, "secret") {
+		t.Error("expected the signature of another secret to be invalid")
+	}
+	if validGitHubSignature(payload, "sha1=757107ea0eb2509fc211221cce984b8a37570b6d", "It's a Secret to Everybody") {
+		t.Error("expected a sha1 signature to be invalid")
+	}
+}
+
+func TestGitHubHookEventRefreshesRepository(t *testing.T) {
+	tests := []struct {
+		event   string
+		payload githubHookEvent
+		refresh bool
+	}{
+		{event: "release", payload: githubHookEvent{Action: "published"}, refresh: true},
+		{event: "create", payload: githubHookEvent{RefType: "tag"}, refresh: true},
+		{event: "create", payload: githubHookEvent{RefType: "branch"}, refresh: false},
+		{event: "push", payload: githubHookEvent{Ref: "refs/tags/v1.0.0"}, refresh: true},
+		{event: "push", payload: githubHookEvent{Ref: "refs/heads/master"}, refresh: true},
+		{event: "push", payload: githubHookEvent{Ref: "refs/heads/feature"}, refresh: false},
+		{event: "repository", payload: githubHookEvent{Action: "archived"}, refresh: true},
+		{event: "repository", payload: githubHookEvent{Action: "deleted"}, refresh: false},
+		{event: "issues", payload: githubHookEvent{Action: "opened"}, refresh: false},
+	}
+
+	for _, tt := range tests {
+		tt.payload.Repository.FullName = "foo/bar"
+		tt.payload.Repository.DefaultBranch = "master"
+		if refresh := tt.payload.refreshesRepository(tt.event); refresh != tt.refresh {
+			t.Errorf("expected %s event %+v to refresh: %v, got %v", tt.event, tt.payload, tt.refresh, refresh)
+		}
+	}
+}
+
+func TestGitHubHookHandler(t *testing.T) {
+	queue := NewRefreshQueue(2)
+	repositories := indexedService{indexed: map[string]bool{"github.com/foo/bar": true, "github.com/foo/baz": true}}
+	handler := GitHubHookHandler(repositories, queue, NewRateLimiter(1, time.Hour), "It's a Secret to Everybody")
+
+	sign := func(payload string) string {
+		h := hmac.New(sha256.New, []byte("It's a Secret to Everybody"))
+		h.Write([]byte(payload))
+		return "sha256=" + hex.EncodeToString(h.Sum(nil))
+	}
+	payload := func(name string) string {
+		return `{"ref":"refs/tags/v1.0.0","repository":{"full_name":"` + name + `","default_branch":"master"}}`
+	}
+
+	tests := []struct {
+		payload   string
+		signature string
+		status    int
+		run       bool
+	}{
+		{payload: payload("foo/bar"), signature: "sha256=00", status: http.StatusUnauthorized},
+		{payload: payload("foo/bar"), signature: sign(payload("foo/bar")), status: http.StatusAccepted},
+		// The repository is already queued
+		{payload: payload("foo/bar"), signature: sign(payload("foo/bar")), status: http.StatusAccepted, run: true},
+		// Once it ran, it's limited
+		{payload: payload("foo/bar"), signature: sign(payload("foo/bar")), status: http.StatusTooManyRequests},
+		// Repositories that aren't indexed aren't queued
+		{payload: payload("foo/new"), signature: sign(payload("foo/new")), status: http.StatusNoContent},
+		{payload: payload("foo/baz"), signature: sign(payload("foo/baz")), status: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/hooks/github", strings.NewReader(tt.payload))
+		r.Header.Set("X-GitHub-Event", "push")
+		r.Header.Set("X-Hub-Signature-256", tt.signature)
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("expected status %d for %s, got %d", tt.status, tt.payload, w.Code)
+		}
+		if tt.run {
+			url, _ := queue.Next(context.Background())
+			queue.Done(url, nil)
+		}
+	}
+
+	if url, _ := queue.Next(context.Background()); url != "github.com/foo/baz" {
+		t.Errorf("expected only github.com/foo/baz to be queued, got %s", url)
+	}
+	if _, ok := queue.Status("github.com/foo/new"); ok {
+		t.Error("expected github.com/foo/new not to be queued")
+	}
+}
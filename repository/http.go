@@ -5,41 +5,350 @@ import (
 	"html/template"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 )
 
-// GitHubHandler renders and responds with a html page to a http request
-func GitHubHandler(repositories Service, tmpl *template.Template, notfoundTmpl *template.Template) http.HandlerFunc {
+// githubURL returns the url of the GitHub repository requested by its owner and name
+func githubURL(r *http.Request) (string, error) {
+	uri, err := url.Parse(fmt.Sprintf("github.com/%s/%s", chi.URLParam(r, "owner"), chi.URLParam(r, "name")))
+	if err != nil {
+		return "", err
+	}
+	return uri.String(), nil
+}
+
+// GitHubHandler renders and responds with a html page to a http request.
+// Current versions that weren't analyzed yet are queued to be analyzed in the background.
+func GitHubHandler(repositories Service, refreshes *RefreshQueue, analyses *RefreshQueue, tmpl *template.Template, errorTmpl *template.Template) http.HandlerFunc {
+	type Page struct {
+		Title         string
+		Repository    Repository
+		Refresh       *RefreshStatus
+		Module        Module
+		ImportPath    string
+		Incompatible  bool
+		CurrentModule *ModuleVersion
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+
+		uri, err := githubURL(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		repo, err := repositories.Get(r.Context(), uri)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		major := selectedMajor(repo)
+		if m := r.URL.Query().Get("major"); m != "" {
+			major, err = strconv.Atoi(m)
+			if err != nil {
+				http.Error(w, "invalid major version", http.StatusBadRequest)
+				return
+			}
+		}
+		module, _ := repo.Module(major)
+
+		p := Page{
+			Title:        fmt.Sprintf("%s - ", name),
+			Repository:   repo,
+			Module:       module,
+			ImportPath:   repo.ImportPath(major),
+			Incompatible: major >= 2 && module.Path != expectedModulePath(repo.URL, major),
+		}
+		if s, ok := refreshes.Status(repo.URL); ok {
+			p.Refresh = &s
+		}
+
+		// The dependencies of the current version are left out until it's analyzed,
+		// the repository's page is still useful without them.
+		if repo.CurrentVersion.Name != "" {
+			mv, err := repositories.StoredModuleVersion(r.Context(), repo.URL, repo.CurrentVersion.Name)
+			if err == nil {
+				p.CurrentModule = &mv
+			}
+			if err == ErrNotFound {
+				analyses.Enqueue(repo.URL)
+			}
+		}
+
+		if err := tmpl.ExecuteTemplate(w, "layout", p); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// NotFoundHandler responds with the not found page
+func NotFoundHandler(errorTmpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeErrorPage(w, errorTmpl, ErrNotFound)
+	}
+}
+
+// selectedMajor returns the major version of the current version
+// or the highest major version with a module if there is none.
+func selectedMajor(repo Repository) int {
+	if major, ok := majorOf(repo.CurrentVersion.Name); ok {
+		return major
+	}
+	if len(repo.Modules) > 0 {
+		return repo.Modules[len(repo.Modules)-1].Major
+	}
+	return 0
+}
+
+// VersionsHandler renders a paginated html page of all versions of a repository
+func VersionsHandler(repositories Service, tmpl *template.Template, errorTmpl *template.Template) http.HandlerFunc {
 	type Page struct {
 		Title      string
 		Repository Repository
+		Versions   VersionList
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		owner := chi.URLParam(r, "owner")
 		name := chi.URLParam(r, "name")
 
-		uri, err := url.Parse(fmt.Sprintf("github.com/%s/%s", owner, name))
+		uri, err := githubURL(r)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		repo, err := repositories.Get(r.Context(), uri.String())
-		if err == ErrNotFound {
-			w.WriteHeader(http.StatusNotFound)
-			notfoundTmpl.ExecuteTemplate(w, "layout", nil)
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			page, err = strconv.Atoi(p)
+			if err != nil || page < 1 {
+				http.Error(w, "invalid page", http.StatusBadRequest)
+				return
+			}
+		}
+
+		repo, err := repositories.Get(r.Context(), uri)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		versions, err := repositories.Versions(r.Context(), repo.URL, page)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		p := Page{
+			Title:      fmt.Sprintf("%s versions - ", name),
+			Repository: repo,
+			Versions:   versions,
+		}
+
+		if err := tmpl.ExecuteTemplate(w, "layout", p); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+	}
+}
+
+// VersionHandler renders a html page of a repository as of one of its versions
+func VersionHandler(repositories Service, tmpl *template.Template, errorTmpl *template.Template) http.HandlerFunc {
+	type Page struct {
+		Title       string
+		Repository  Repository
+		Version     string
+		Module      ModuleVersion
+		Description string
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+		version := chi.URLParam(r, "version")
+
+		uri, err := githubURL(r)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		repo, err := repositories.Get(r.Context(), uri)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		mv, err := repositories.ModuleVersion(r.Context(), repo.URL, version)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		p := Page{
+			Title:       fmt.Sprintf("%s %s - ", name, version),
+			Repository:  repo,
+			Version:     version,
+			Module:      mv,
+			Description: repo.Description,
+		}
+		// The root package's documentation describes the module as of this version
+		for _, pkg := range mv.Packages {
+			if pkg.ImportPath == mv.Path && pkg.Synopsis != "" {
+				p.Description = pkg.Synopsis
+			}
+		}
+
+		if err := tmpl.ExecuteTemplate(w, "layout", p); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// CompareHandler renders a html page with the changes between two versions of a repository
+func CompareHandler(repositories Service, tmpl *template.Template, errorTmpl *template.Template) http.HandlerFunc {
+	type Page struct {
+		Title      string
+		Repository Repository
+		FromTag    string
+		ToTag      string
+		Comparison Comparison
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+
+		versions := strings.SplitN(chi.URLParam(r, "versions"), "...", 2)
+		if len(versions) != 2 || versions[0] == "" || versions[1] == "" {
+			http.Error(w, "versions need to be compared like v1.0.0...v1.1.0", http.StatusBadRequest)
+			return
+		}
+		from, to := versions[0], versions[1]
+
+		uri, err := githubURL(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		repo, err := repositories.Get(r.Context(), uri)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		comparison, err := repositories.Compare(r.Context(), repo.URL, from, to)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		p := Page{
+			Title:      fmt.Sprintf("%s %s...%s - ", name, from, to),
+			Repository: repo,
+			FromTag:    from,
+			ToTag:      to,
+			Comparison: comparison,
+		}
+
+		if err := tmpl.ExecuteTemplate(w, "layout", p); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// GraphHandler responds with the dependency graph of a repository's version
+// as svg, or as dot or json if requested by the format query parameter.
+func GraphHandler(repositories Service, errorTmpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uri, err := githubURL(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		depth := defaultGraphDepth
+		if d := r.URL.Query().Get("depth"); d != "" {
+			depth, err = strconv.Atoi(d)
+			if err != nil || depth < 1 || depth > maxGraphDepth {
+				http.Error(w, fmt.Sprintf("depth needs to be between 1 and %d", maxGraphDepth), http.StatusBadRequest)
+				return
+			}
+		}
+
+		graph, err := repositories.Graph(r.Context(), uri, r.URL.Query().Get("version"), depth)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		switch r.URL.Query().Get("format") {
+		case "", "svg":
+			svg, err := graph.SVG()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write(svg)
+		case "dot":
+			w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+			w.Write([]byte(graph.DOT()))
+		case "json":
+			writeJSON(w, http.StatusOK, graph)
+		default:
+			http.Error(w, "format needs to be one of svg, dot or json", http.StatusBadRequest)
+		}
+	}
+}
+
+// LicensesHandler renders a html page with the licenses of a version and its dependencies
+func LicensesHandler(repositories Service, tmpl *template.Template, errorTmpl *template.Template) http.HandlerFunc {
+	type Page struct {
+		Title      string
+		Repository Repository
+		Version    string
+		Report     LicenseReport
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "name")
+
+		uri, err := githubURL(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		repo, err := repositories.Get(r.Context(), uri)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
+		version := r.URL.Query().Get("version")
+		if version == "" {
+			version = repo.CurrentVersion.Name
+		}
+
+		report, err := repositories.Licenses(r.Context(), repo.URL, version)
+		if err != nil {
+			writeErrorPage(w, errorTmpl, err)
+			return
+		}
+
 		p := Page{
-			Title:      fmt.Sprintf("%s - ", name),
+			Title:      fmt.Sprintf("%s %s licenses - ", name, version),
 			Repository: repo,
+			Version:    version,
+			Report:     report,
 		}
 
 		if err := tmpl.ExecuteTemplate(w, "layout", p); err != nil {
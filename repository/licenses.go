@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+)
+
+// LicenseCategory groups licenses by the obligations they put on their users
+type LicenseCategory string
+
+// Categories of licenses, ordered from the least to the most restrictive
+const (
+	Permissive     LicenseCategory = "permissive"
+	WeakCopyleft   LicenseCategory = "weak-copyleft"
+	StrongCopyleft LicenseCategory = "strong-copyleft"
+	UnknownLicense LicenseCategory = "unknown"
+)
+
+var licenseCategoryOrder = map[LicenseCategory]int{
+	Permissive:     0,
+	WeakCopyleft:   1,
+	StrongCopyleft: 2,
+	UnknownLicense: 3,
+}
+
+// licenseCategories maps SPDX IDs, without -only and -or-later suffixes, to their category
+var licenseCategories = map[string]LicenseCategory{
+	"0BSD":               Permissive,
+	"Apache-1.1":         Permissive,
+	"Apache-2.0":         Permissive,
+	"Artistic-2.0":       Permissive,
+	"BSD-2-Clause":       Permissive,
+	"BSD-3-Clause":       Permissive,
+	"BSD-3-Clause-Clear": Permissive,
+	"BSD-4-Clause":       Permissive,
+	"BSL-1.0":            Permissive,
+	"CC0-1.0":            Permissive,
+	"ISC":                Permissive,
+	"MIT":                Permissive,
+	"MIT-0":              Permissive,
+	"NCSA":               Permissive,
+	"PostgreSQL":         Permissive,
+	"Python-2.0":         Permissive,
+	"Unlicense":          Permissive,
+	"UPL-1.0":            Permissive,
+	"WTFPL":              Permissive,
+	"X11":                Permissive,
+	"Zlib":               Permissive,
+
+	"CDDL-1.0": WeakCopyleft,
+	"CDDL-1.1": WeakCopyleft,
+	"CPL-1.0":  WeakCopyleft,
+	"EPL-1.0":  WeakCopyleft,
+	"EPL-2.0":  WeakCopyleft,
+	"LGPL-2.0": WeakCopyleft,
+	"LGPL-2.1": WeakCopyleft,
+	"LGPL-3.0": WeakCopyleft,
+	"MPL-1.1":  WeakCopyleft,
+	"MPL-2.0":  WeakCopyleft,
+
+	"AGPL-3.0":     StrongCopyleft,
+	"CC-BY-SA-4.0": StrongCopyleft,
+	"EUPL-1.1":     StrongCopyleft,
+	"EUPL-1.2":     StrongCopyleft,
+	"GPL-2.0":      StrongCopyleft,
+	"GPL-3.0":      StrongCopyleft,
+	"OSL-3.0":      StrongCopyleft,
+	"SSPL-1.0":     StrongCopyleft,
+}
+
+// normalizeSPDX strips the suffixes of an SPDX ID that don't change its category
+func normalizeSPDX(id string) string {
+	id = strings.TrimSpace(strings.Trim(strings.TrimSpace(id), "()"))
+	id = strings.TrimSuffix(id, "+")
+	id = strings.TrimSuffix(id, "-only")
+	id = strings.TrimSuffix(id, "-or-later")
+	return id
+}
+
+// licenseCategory returns the category of an SPDX license expression.
+// Of licenses combined with OR the least restrictive one applies,
+// of licenses combined with AND the most restrictive one.
+func licenseCategory(expr string) LicenseCategory {
+	if parts := strings.Split(expr, " OR "); len(parts) > 1 {
+		category := UnknownLicense
+		for _, p := range parts {
+			if c := licenseCategory(p); licenseCategoryOrder[c] < licenseCategoryOrder[category] {
+				category = c
+			}
+		}
+		return category
+	}
+	if parts := strings.Split(expr, " AND "); len(parts) > 1 {
+		category := Permissive
+		for _, p := range parts {
+			if c := licenseCategory(p); licenseCategoryOrder[c] > licenseCategoryOrder[category] {
+				category = c
+			}
+		}
+		return category
+	}
+
+	if c, ok := licenseCategories[normalizeSPDX(expr)]; ok {
+		return c
+	}
+	return UnknownLicense
+}
+
+// LicensePolicy denies license categories or single SPDX IDs
+type LicensePolicy struct {
+	Deny []string `json:"deny"`
+}
+
+// ParseLicensePolicy parses a comma separated list of denied categories and SPDX IDs
+func ParseLicensePolicy(s string) LicensePolicy {
+	var p LicensePolicy
+	for _, d := range strings.Split(s, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			p.Deny = append(p.Deny, d)
+		}
+	}
+	return p
+}
+
+// conflicts returns true if the policy denies a license
+func (p LicensePolicy) conflicts(spdx string, category LicenseCategory) bool {
+	for _, d := range p.Deny {
+		if LicenseCategory(d) == category || normalizeSPDX(d) == normalizeSPDX(spdx) {
+			return true
+		}
+	}
+	return false
+}
+
+type (
+	// LicenseReport lists the licenses of a module version and all modules of its build list
+	LicenseReport struct {
+		Path       string                  `json:"path"`
+		Version    string                  `json:"version"`
+		Policy     LicensePolicy           `json:"policy"`
+		Modules    []LicensedModule        `json:"modules"`
+		Categories map[LicenseCategory]int `json:"categories"`
+		Conflicts  int                     `json:"conflicts"`
+	}
+	// LicensedModule is a module version with its license
+	LicensedModule struct {
+		Path     string          `json:"path"`
+		Version  string          `json:"version"`
+		License  string          `json:"license"`
+		Category LicenseCategory `json:"category"`
+		Conflict bool            `json:"conflict"`
+	}
+)
+
+// newLicenseReport classifies the licenses of modules, given by their path, and checks them against a policy
+func newLicenseReport(path, version string, modules []Dependency, licenses map[string]string, policy LicensePolicy) LicenseReport {
+	r := LicenseReport{
+		Path:       path,
+		Version:    version,
+		Policy:     policy,
+		Categories: map[LicenseCategory]int{},
+	}
+
+	for _, m := range modules {
+		lm := LicensedModule{
+			Path:     m.Path,
+			Version:  m.Version,
+			License:  licenses[m.Path],
+			Category: licenseCategory(licenses[m.Path]),
+		}
+		lm.Conflict = policy.conflicts(lm.License, lm.Category)
+
+		r.Categories[lm.Category]++
+		if lm.Conflict {
+			r.Conflicts++
+		}
+		r.Modules = append(r.Modules, lm)
+	}
+
+	// The module itself comes first, followed by its dependencies
+	sort.SliceStable(r.Modules, func(i, j int) bool {
+		if r.Modules[i].Path == path || r.Modules[j].Path == path {
+			return r.Modules[i].Path == path && r.Modules[j].Path != path
+		}
+		return r.Modules[i].Path < r.Modules[j].Path
+	})
+
+	return r
+}
+
+// moduleRepository returns the url of the GitHub repository of a module path,
+// or an empty string if the module isn't hosted on GitHub.
+func moduleRepository(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || parts[0] != "github.com" {
+		return ""
+	}
+	return strings.Join(parts[:3], "/")
+}
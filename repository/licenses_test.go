@@ -0,0 +1,68 @@
+package repository
+
+import "testing"
+
+func TestLicenseCategory(t *testing.T) {
+	tests := []struct {
+		expr     string
+		category LicenseCategory
+	}{
+		{expr: "MIT", category: Permissive},
+		{expr: "Apache-2.0", category: Permissive},
+		{expr: "MPL-2.0", category: WeakCopyleft},
+		{expr: "LGPL-2.1-or-later", category: WeakCopyleft},
+		{expr: "GPL-3.0-only", category: StrongCopyleft},
+		{expr: "GPL-2.0+", category: StrongCopyleft},
+		{expr: "AGPL-3.0", category: StrongCopyleft},
+		{expr: "NOASSERTION", category: UnknownLicense},
+		{expr: "", category: UnknownLicense},
+		{expr: "MIT OR GPL-3.0", category: Permissive},
+		{expr: "MIT AND GPL-3.0", category: StrongCopyleft},
+	}
+
+	for _, tt := range tests {
+		if c := licenseCategory(tt.expr); c != tt.category {
+			t.Errorf("expected %q to be %s, got %s", tt.expr, tt.category, c)
+		}
+	}
+}
+
+func TestNewLicenseReport(t *testing.T) {
+	modules := []Dependency{
+		{Path: "github.com/foo/bar", Version: "v1.0.0"},
+		{Path: "github.com/pkg/errors", Version: "v0.8.0"},
+		{Path: "github.com/gpl/lib", Version: "v2.1.0"},
+		{Path: "golang.org/x/sync", Version: "v0.1.0"},
+		{Path: "github.com/hashicorp/go-version", Version: "v1.0.0"},
+	}
+	licenses := map[string]string{
+		"github.com/foo/bar":              "MIT",
+		"github.com/pkg/errors":           "BSD-2-Clause",
+		"github.com/gpl/lib":              "GPL-3.0",
+		"github.com/hashicorp/go-version": "MPL-2.0",
+	}
+
+	r := newLicenseReport("github.com/foo/bar", "v1.0.0", modules, licenses, ParseLicensePolicy("strong-copyleft, MPL-2.0-only"))
+
+	expected := []LicensedModule{
+		{Path: "github.com/foo/bar", Version: "v1.0.0", License: "MIT", Category: Permissive},
+		{Path: "github.com/gpl/lib", Version: "v2.1.0", License: "GPL-3.0", Category: StrongCopyleft, Conflict: true},
+		{Path: "github.com/hashicorp/go-version", Version: "v1.0.0", License: "MPL-2.0", Category: WeakCopyleft, Conflict: true},
+		{Path: "github.com/pkg/errors", Version: "v0.8.0", License: "BSD-2-Clause", Category: Permissive},
+		{Path: "golang.org/x/sync", Version: "v0.1.0", Category: UnknownLicense},
+	}
+	if len(r.Modules) != len(expected) {
+		t.Fatalf("expected %d modules, got %+v", len(expected), r.Modules)
+	}
+	for i, m := range expected {
+		if r.Modules[i] != m {
+			t.Errorf("expected %+v, got %+v", m, r.Modules[i])
+		}
+	}
+	if r.Conflicts != 2 {
+		t.Errorf("expected 2 conflicts, got %d", r.Conflicts)
+	}
+	if r.Categories[Permissive] != 2 || r.Categories[UnknownLicense] != 1 {
+		t.Errorf("unexpected categories %v", r.Categories)
+	}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+// missingTTL is the time a repository that wasn't found isn't requested again
+const missingTTL = 24 * time.Hour
+
+// missingCache remembers repositories that weren't found in memory, until their TTL expires
+type missingCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	expires map[string]time.Time
+	pruned  time.Time
+}
+
+func newMissingCache(ttl time.Duration) *missingCache {
+	return &missingCache{
+		ttl:     ttl,
+		expires: map[string]time.Time{},
+	}
+}
+
+// missing returns if a repository wasn't found within the TTL
+func (c *missingCache) missing(url string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return now.Before(c.expires[url])
+}
+
+// add remembers a repository that wasn't found at a time
+func (c *missingCache) add(url string, checked time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.prune(time.Now())
+	c.expires[url] = checked.Add(c.ttl)
+}
+
+// prune forgets expired repositories, at most once a minute
+func (c *missingCache) prune(now time.Time) {
+	if now.Sub(c.pruned) < time.Minute {
+		return
+	}
+	c.pruned = now
+
+	for url, expires := range c.expires {
+		if !now.Before(expires) {
+			delete(c.expires, url)
+		}
+	}
+}
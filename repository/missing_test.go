@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMissingCache(t *testing.T) {
+	now := time.Now()
+	c := newMissingCache(time.Hour)
+
+	if c.missing("github.com/foo/bar", now) {
+		t.Error("expected an unknown repository not to be missing")
+	}
+
+	c.add("github.com/foo/bar", now.Add(-30*time.Minute))
+	if !c.missing("github.com/foo/bar", now) {
+		t.Error("expected the repository to be missing within the ttl")
+	}
+	if c.missing("github.com/foo/bar", now.Add(30*time.Minute)) {
+		t.Error("expected the repository not to be missing after the ttl")
+	}
+}
+
+func TestMissingRepository(t *testing.T) {
+	if !missingRepository(errors.New("Could not resolve to a Repository with the name 'foo/bar'.")) {
+		t.Error("expected a missing repository")
+	}
+	if missingRepository(errors.New("API rate limit exceeded")) {
+		t.Error("expected a rate limit not to be a missing repository")
+	}
+}
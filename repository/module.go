@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MajorVersion groups the versions of a Repository's major version
+// together with the Module they belong to.
+// Versions that aren't semantic versions are grouped with a Major of -1.
+type MajorVersion struct {
+	Module
+	Versions []Version
+}
+
+// majorOf returns the major version of a semantic version
+func majorOf(version string) (int, bool) {
+	sv, ok := parseSemver(version)
+	return sv.major, ok
+}
+
+// expectedModulePath returns the module path that Go expects
+// for a major version of a repository, like github.com/foo/bar/v2.
+func expectedModulePath(url string, major int) string {
+	if major < 2 {
+		return url
+	}
+	return fmt.Sprintf("%s/v%d", url, major)
+}
+
+// Module returns the Module of a major version
+func (r Repository) Module(major int) (Module, bool) {
+	for _, m := range r.Modules {
+		if m.Major == major {
+			return m, true
+		}
+	}
+	return Module{Major: major}, false
+}
+
+// ImportPath returns the path to import a major version with.
+// Major versions without a matching go.mod are imported
+// without the /vN suffix as +incompatible versions.
+func (r Repository) ImportPath(major int) string {
+	if m, ok := r.Module(major); ok && m.Path != "" {
+		return m.Path
+	}
+	return r.URL
+}
+
+// IncompatibleMajors returns all v2+ major versions that were
+// tagged without a go.mod declaring the matching module path.
+func (r Repository) IncompatibleMajors() []Module {
+	var modules []Module
+	for _, m := range r.Modules {
+		if m.Major >= 2 && m.Path != expectedModulePath(r.URL, m.Major) {
+			modules = append(modules, m)
+		}
+	}
+	return modules
+}
+
+// MajorVersions groups the Repository's versions by their major version,
+// the highest major version first. Only the latest versions are loaded,
+// so major versions of the Repository's Modules without any of them are grouped without versions.
+func (r Repository) MajorVersions() []MajorVersion {
+	groups := map[int]*MajorVersion{}
+	var majors []int
+
+	for _, v := range r.Versions {
+		major, ok := majorOf(v.Name)
+		if !ok {
+			major = -1
+		}
+
+		g, ok := groups[major]
+		if !ok {
+			m, _ := r.Module(major)
+			g = &MajorVersion{Module: m}
+			groups[major] = g
+			majors = append(majors, major)
+		}
+		g.Versions = append(g.Versions, v)
+	}
+	for _, m := range r.Modules {
+		if _, ok := groups[m.Major]; !ok {
+			groups[m.Major] = &MajorVersion{Module: m}
+			majors = append(majors, m.Major)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(majors)))
+
+	mvs := make([]MajorVersion, 0, len(majors))
+	for _, major := range majors {
+		mvs = append(mvs, *groups[major])
+	}
+	return mvs
+}
+
+// latestMajorVersions returns the latest tag of every major version that
+// can be used as module version, preferring stable versions over prereleases.
+// The versions are expected to be sorted with the lowest precedence first.
+func latestMajorVersions(versions []Version) map[int]Version {
+	latest := map[int]Version{}
+	for _, v := range versions {
+		sv, ok := parseSemver(v.Name)
+		if !ok || v.Draft || sv.String() != v.Name {
+			continue
+		}
+		if l, ok := latest[sv.major]; ok && !l.Prerelease && v.Prerelease {
+			continue
+		}
+		latest[sv.major] = v
+	}
+	return latest
+}
@@ -0,0 +1,54 @@
+package repository
+
+import "testing"
+
+func TestRepositoryMajorVersions(t *testing.T) {
+	repo := Repository{
+		URL: "github.com/foo/bar",
+		Modules: []Module{
+			{Major: 0, Path: "github.com/foo/bar", Version: "v0.9.0"},
+			{Major: 1, Path: "github.com/foo/bar", Version: "v1.1.0"},
+			{Major: 2, Path: "github.com/foo/bar/v2", Version: "v2.0.0"},
+			{Major: 3, Version: "v3.0.0"},
+		},
+		Versions: []Version{
+			{Name: "v3.0.0"},
+			{Name: "v2.0.0"},
+			{Name: "v1.1.0"},
+			{Name: "v1.0.0"},
+			{Name: "weekly.2011-12-22"},
+		},
+	}
+
+	majors := repo.MajorVersions()
+	expected := []struct {
+		major    int
+		path     string
+		versions int
+	}{
+		{3, "", 1},
+		{2, "github.com/foo/bar/v2", 1},
+		{1, "github.com/foo/bar", 2},
+		// Major versions older than the latest versions
+		{0, "github.com/foo/bar", 0},
+		{-1, "", 1},
+	}
+	if len(majors) != len(expected) {
+		t.Fatalf("expected %d major versions, got %d", len(expected), len(majors))
+	}
+	for i, e := range expected {
+		if majors[i].Major != e.major || majors[i].Path != e.path || len(majors[i].Versions) != e.versions {
+			t.Errorf("expected major version %+v, got %+v", e, majors[i])
+		}
+	}
+
+	if path := repo.ImportPath(2); path != "github.com/foo/bar/v2" {
+		t.Errorf("expected import path github.com/foo/bar/v2, got %s", path)
+	}
+	if path := repo.ImportPath(3); path != "github.com/foo/bar" {
+		t.Errorf("expected import path github.com/foo/bar, got %s", path)
+	}
+	if incompatible := repo.IncompatibleMajors(); len(incompatible) != 1 || incompatible[0].Major != 3 {
+		t.Errorf("expected only v3 to be incompatible, got %+v", incompatible)
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"sort"
+)
+
+// versionLess returns true if version a has a lower precedence than b.
+// Versions that aren't semantic versions are compared as strings.
+func versionLess(a, b string) bool {
+	av, aok := parseSemver(a)
+	bv, bok := parseSemver(b)
+	if !aok || !bok {
+		return a < b
+	}
+	return compareSemver(av, bv) < 0
+}
+
+// buildList computes the build list of a module with minimal version selection,
+// like go list -m all does. Every module version reachable through the
+// requirements is visited and the highest required version of each module
+// is selected. Dependencies not required by the module itself are indirect.
+// The module's replace and exclude directives are applied, as it's the main module:
+// Requirements of excluded versions are ignored and the requirements of replaced
+// versions are the ones of their replacement. Directories replacing modules
+// can't be read, so they are treated like they had no requirements.
+func buildList(mod GoMod, requirements func(path, version string) ([]Dependency, error)) ([]Dependency, error) {
+	direct := map[string]bool{}
+	for _, d := range mod.Require {
+		if !d.Indirect {
+			direct[d.Path] = true
+		}
+	}
+
+	selected := map[string]string{}
+	visited := map[string]bool{}
+	queue := append([]Dependency(nil), mod.Require...)
+
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+
+		if d.Path == mod.Module || visited[graphID(d.Path, d.Version)] || mod.excludes(d.Path, d.Version) {
+			continue
+		}
+		visited[graphID(d.Path, d.Version)] = true
+
+		if v, ok := selected[d.Path]; !ok || versionLess(v, d.Version) {
+			selected[d.Path] = d.Version
+		}
+
+		r := d
+		if replacement, ok := mod.replacement(d.Path, d.Version); ok {
+			if replacement.Version == "" {
+				continue
+			}
+			r = replacement
+		}
+		reqs, err := requirements(r.Path, r.Version)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, reqs...)
+	}
+
+	list := make([]Dependency, 0, len(selected))
+	for path, version := range selected {
+		d := Dependency{Path: path, Version: version, Indirect: !direct[path]}
+		if replacement, ok := mod.replacement(path, version); ok {
+			d.Replace = formatModule(replacement)
+		}
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+
+	return list, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuildList(t *testing.T) {
+	// The example of the minimal version selection article by Russ Cox
+	requirements := map[string][]Dependency{
+		"b@v1.2.0": {{Path: "d", Version: "v1.3.0"}},
+		"c@v1.2.0": {{Path: "d", Version: "v1.4.0"}},
+		"d@v1.3.0": {{Path: "e", Version: "v1.2.0"}},
+		"d@v1.4.0": {{Path: "e", Version: "v1.2.0"}},
+		"e@v1.2.0": {},
+		"e@v1.3.0": {{Path: "a", Version: "v1.0.0"}},
+	}
+
+	mod := GoMod{
+		Module: "a",
+		Require: []Dependency{
+			{Path: "b", Version: "v1.2.0"},
+			{Path: "c", Version: "v1.2.0"},
+			{Path: "e", Version: "v1.3.0", Indirect: true},
+		},
+	}
+
+	list, err := buildList(mod, func(path, version string) ([]Dependency, error) {
+		reqs, ok := requirements[path+"@"+version]
+		if !ok {
+			t.Errorf("unexpected requirements of %s@%s", path, version)
+		}
+		return reqs, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Dependency{
+		{Path: "b", Version: "v1.2.0"},
+		{Path: "c", Version: "v1.2.0"},
+		{Path: "d", Version: "v1.4.0", Indirect: true},
+		{Path: "e", Version: "v1.3.0", Indirect: true},
+	}
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d modules, got %+v", len(expected), list)
+	}
+	for i, d := range expected {
+		if list[i] != d {
+			t.Errorf("expected %+v, got %+v", d, list[i])
+		}
+	}
+}
+
+func TestBuildListReplaceExclude(t *testing.T) {
+	requirements := map[string][]Dependency{
+		"b@v1.0.0":    {{Path: "c", Version: "v1.1.0"}},
+		"c@v1.0.0":    {},
+		"fork@v1.0.0": {{Path: "e", Version: "v1.0.0"}},
+		"e@v1.0.0":    {},
+	}
+
+	mod := GoMod{
+		Module: "a",
+		Require: []Dependency{
+			{Path: "b", Version: "v1.0.0"},
+			{Path: "c", Version: "v1.0.0"},
+			{Path: "d", Version: "v1.0.0"},
+			{Path: "local", Version: "v1.0.0"},
+		},
+		Replace: []Replacement{
+			{Old: Dependency{Path: "d"}, New: Dependency{Path: "fork", Version: "v1.0.0"}},
+			{Old: Dependency{Path: "local", Version: "v1.0.0"}, New: Dependency{Path: "./local"}},
+		},
+		Exclude: []Dependency{{Path: "c", Version: "v1.1.0"}},
+	}
+
+	list, err := buildList(mod, func(path, version string) ([]Dependency, error) {
+		reqs, ok := requirements[path+"@"+version]
+		if !ok {
+			t.Errorf("unexpected requirements of %s@%s", path, version)
+		}
+		return reqs, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Dependency{
+		{Path: "b", Version: "v1.0.0"},
+		{Path: "c", Version: "v1.0.0"},
+		{Path: "d", Version: "v1.0.0", Replace: "fork v1.0.0"},
+		{Path: "e", Version: "v1.0.0", Indirect: true},
+		{Path: "local", Version: "v1.0.0", Replace: "./local"},
+	}
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d modules, got %+v", len(expected), list)
+	}
+	for i, d := range expected {
+		if list[i] != d {
+			t.Errorf("expected %+v, got %+v", d, list[i])
+		}
+	}
+}
+
+func TestBuildListError(t *testing.T) {
+	mod := GoMod{Module: "a", Require: []Dependency{{Path: "b", Version: "v1.0.0"}}}
+
+	_, err := buildList(mod, func(path, version string) ([]Dependency, error) {
+		return nil, errors.New("proxy unavailable")
+	})
+	if err == nil {
+		t.Error("expected the error of the requirements to be returned")
+	}
+}
+
+func TestVersionLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		less bool
+	}{
+		{a: "v1.2.0", b: "v1.10.0", less: true},
+		{a: "v1.10.0", b: "v1.2.0", less: false},
+		{a: "v0.0.0-20180101000000-abcdef123456", b: "v0.1.0", less: true},
+		{a: "v2.0.0+incompatible", b: "v2.1.0+incompatible", less: true},
+		{a: "v1.0.0", b: "v1.0.0", less: false},
+	}
+
+	for _, tt := range tests {
+		if less := versionLess(tt.a, tt.b); less != tt.less {
+			t.Errorf("expected versionLess(%s, %s) to be %t", tt.a, tt.b, tt.less)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EventType is the kind of change a Subscription is notified about
+type EventType string
+
+// Types of events
+const (
+	EventVersion  EventType = "version"
+	EventArchived EventType = "archived"
+	EventAdvisory EventType = "advisory"
+)
+
+// WebhookFormat is the payload format of a Subscription's webhook
+type WebhookFormat string
+
+// Formats of webhook payloads
+const (
+	// WebhookJSON posts events as they are encoded as json
+	WebhookJSON WebhookFormat = "json"
+	// WebhookSlack posts events as message compatible with Slack's incoming webhooks
+	WebhookSlack WebhookFormat = "slack"
+)
+
+// DeliveryStatus is the state of a Delivery
+type DeliveryStatus string
+
+// States of deliveries
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+type (
+	// Event is a change of a watched Repository
+	Event struct {
+		Type          EventType      `json:"type"`
+		Repository    string         `json:"repository"`
+		Version       string         `json:"version,omitempty"`
+		Vulnerability *Vulnerability `json:"vulnerability,omitempty"`
+		Time          time.Time      `json:"time"`
+	}
+	// Subscription notifies a webhook about events of the repositories on its watchlist
+	Subscription struct {
+		ID           string        `json:"id"`
+		URL          string        `json:"url"`
+		Format       WebhookFormat `json:"format"`
+		Repositories []string      `json:"repositories"`
+		Created      time.Time     `json:"created"`
+		Deliveries   []Delivery    `json:"deliveries,omitempty"`
+	}
+	// Delivery is an Event sent, or to be sent, to a Subscription's webhook
+	Delivery struct {
+		ID             int64          `json:"id"`
+		Subscription   string         `json:"-"`
+		URL            string         `json:"-"`
+		Format         WebhookFormat  `json:"-"`
+		Event          Event          `json:"event"`
+		Status         DeliveryStatus `json:"status"`
+		Attempts       int            `json:"attempts"`
+		NextAttempt    time.Time      `json:"next_attempt"`
+		ResponseStatus int            `json:"response_status,omitempty"`
+		Error          string         `json:"error,omitempty"`
+		Created        time.Time      `json:"created"`
+	}
+)
+
+var (
+	// ErrInvalidSubscription is returned when a Subscription can't be created
+	ErrInvalidSubscription = errors.New("invalid subscription")
+	// ErrSubscriptionNotFound is returned when a Subscription was not found
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+)
+
+const (
+	// maxWatchlist is the number of repositories a single Subscription can watch
+	maxWatchlist = 100
+	// maxDeliveryAttempts is the number of times a Delivery is attempted before it fails
+	maxDeliveryAttempts = 8
+	// watchedRefresh is the interval in which watched repositories are refreshed
+	watchedRefresh = time.Hour
+	// watchedChecks is the number of watched repositories refreshed by a single run of CheckSubscriptions
+	watchedChecks = 25
+	// pendingDeliveries is the number of deliveries attempted by a single run of Notify
+	pendingDeliveries = 100
+	// subscriptionDeliveries is the number of deliveries returned as delivery log of a Subscription
+	subscriptionDeliveries = 50
+)
+
+// Key identifies an event, so that a Subscription is only notified once about it
+func (e Event) Key() string {
+	switch e.Type {
+	case EventVersion:
+		return fmt.Sprintf("%s:%s@%s", e.Type, e.Repository, e.Version)
+	case EventAdvisory:
+		return fmt.Sprintf("%s:%s:%s", e.Type, e.Repository, e.Vulnerability.ID)
+	default:
+		return fmt.Sprintf("%s:%s", e.Type, e.Repository)
+	}
+}
+
+// Text describes an event as human readable message
+func (e Event) Text() string {
+	switch e.Type {
+	case EventVersion:
+		return fmt.Sprintf("%s published %s", e.Repository, e.Version)
+	case EventArchived:
+		return fmt.Sprintf("%s was archived", e.Repository)
+	case EventAdvisory:
+		return fmt.Sprintf("%s is affected by %s: %s", e.Repository, e.Vulnerability.ID, e.Vulnerability.Summary)
+	default:
+		return fmt.Sprintf("%s: %s", e.Repository, e.Type)
+	}
+}
+
+// normalize validates a subscription and normalizes its watchlist to repository urls
+func (sub Subscription) normalize() (Subscription, error) {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return sub, errors.Wrap(ErrInvalidSubscription, "url needs to be a http or https url")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !publicIP(ip) {
+		return sub, errors.Wrap(ErrInvalidSubscription, "url needs to be a public address")
+	}
+
+	switch sub.Format {
+	case "":
+		sub.Format = WebhookJSON
+	case WebhookJSON, WebhookSlack:
+	default:
+		return sub, errors.Wrap(ErrInvalidSubscription, "format needs to be json or slack")
+	}
+
+	seen := map[string]bool{}
+	var repos []string
+	for _, r := range sub.Repositories {
+		repo := moduleRepository(strings.TrimPrefix(strings.TrimPrefix(r, "https://"), "http://"))
+		if repo == "" {
+			return sub, errors.Wrapf(ErrInvalidSubscription, "%s is no GitHub repository", r)
+		}
+		if !seen[repo] {
+			seen[repo] = true
+			repos = append(repos, repo)
+		}
+	}
+	if len(repos) == 0 || len(repos) > maxWatchlist {
+		return sub, errors.Wrapf(ErrInvalidSubscription, "a subscription needs to watch 1 to %d repositories", maxWatchlist)
+	}
+	sub.Repositories = repos
+
+	return sub, nil
+}
+
+// host returns the host of a subscription's webhook url
+func (sub Subscription) host() string {
+	u, err := url.Parse(sub.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// repositoryEvents returns the events between two states of a repository.
+// Versions are new if they aren't part of the known version names.
+func repositoryEvents(old Repository, known []string, repo Repository, now time.Time) []Event {
+	var events []Event
+
+	versions := map[string]bool{}
+	for _, name := range known {
+		versions[name] = true
+	}
+	for _, v := range repo.Versions {
+		if versions[v.Name] || v.Draft {
+			continue
+		}
+		e := Event{Type: EventVersion, Repository: repo.URL, Version: v.Name, Time: v.Published}
+		if e.Time.IsZero() {
+			e.Time = now
+		}
+		events = append(events, e)
+	}
+
+	if repo.Archived && !old.Archived {
+		events = append(events, Event{Type: EventArchived, Repository: repo.URL, Time: now})
+	}
+
+	return events
+}
+
+// advisoryEvents returns the events of vulnerabilities affecting a repository,
+// which are dated by the advisory's publishing, so that only subscriptions
+// created before an advisory was published are notified.
+func advisoryEvents(repo Repository) []Event {
+	var events []Event
+	for _, v := range repo.Vulnerabilities {
+		v := v
+		events = append(events, Event{Type: EventAdvisory, Repository: repo.URL, Version: v.Version, Vulnerability: &v, Time: v.Published})
+	}
+	return events
+}
+
+// deliveryBackoff returns the delay before a delivery is attempted again,
+// doubling with each attempt from a minute up to six hours.
+func deliveryBackoff(attempts int) time.Duration {
+	backoff := time.Minute
+	for i := 1; i < attempts && backoff < 6*time.Hour; i++ {
+		backoff *= 2
+	}
+	if backoff > 6*time.Hour {
+		backoff = 6 * time.Hour
+	}
+	return backoff
+}
+
+// attempted returns the delivery updated by the result of an attempt to send it.
+// Failed deliveries are retried with backoff until they failed too many times.
+func (d Delivery) attempted(status int, err error, now time.Time) Delivery {
+	d.Attempts++
+	d.ResponseStatus = status
+	if err == nil {
+		d.Status = DeliveryDelivered
+		d.Error = ""
+		return d
+	}
+
+	d.Error = err.Error()
+	if d.Attempts >= maxDeliveryAttempts {
+		d.Status = DeliveryFailed
+		return d
+	}
+	d.NextAttempt = now.Add(deliveryBackoff(d.Attempts))
+	return d
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestSubscriptionNormalize(t *testing.T) {
+	sub, err := Subscription{
+		URL:          "https://hooks.slack.com/services/T000/B000/XXX",
+		Repositories: []string{"github.com/foo/bar", "https://github.com/foo/bar/v2", "github.com/foo/baz/pkg"},
+	}.normalize()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sub.Format != WebhookJSON {
+		t.Errorf("expected format %s by default, got %s", WebhookJSON, sub.Format)
+	}
+	expected := []string{"github.com/foo/bar", "github.com/foo/baz"}
+	if len(sub.Repositories) != len(expected) || sub.Repositories[0] != expected[0] || sub.Repositories[1] != expected[1] {
+		t.Errorf("expected repositories %v, got %v", expected, sub.Repositories)
+	}
+
+	invalid := []Subscription{
+		{URL: "ftp://example.com", Repositories: []string{"github.com/foo/bar"}},
+		{URL: "https://example.com", Format: "xml", Repositories: []string{"github.com/foo/bar"}},
+		{URL: "https://example.com", Repositories: []string{"golang.org/x/tools"}},
+		{URL: "https://example.com"},
+		{URL: "http://127.0.0.1:8080/hook", Repositories: []string{"github.com/foo/bar"}},
+		{URL: "http://169.254.169.254/latest/meta-data", Repositories: []string{"github.com/foo/bar"}},
+		{URL: "http://[::1]/hook", Repositories: []string{"github.com/foo/bar"}},
+	}
+	for _, sub := range invalid {
+		if _, err := sub.normalize(); errors.Cause(err) != ErrInvalidSubscription {
+			t.Errorf("expected %+v to be invalid, got %v", sub, err)
+		}
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	}
+	for ip, public := range tests {
+		if publicIP(net.ParseIP(ip)) != public {
+			t.Errorf("expected %s to be public: %v", ip, public)
+		}
+	}
+}
+
+func TestCheckPublicHost(t *testing.T) {
+	defer func(lookup func(context.Context, string) ([]net.IPAddr, error)) { lookupIPAddr = lookup }(lookupIPAddr)
+	lookupIPAddr = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		if host == "internal.example.com" {
+			return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}, {IP: net.ParseIP("10.0.0.1")}}, nil
+		}
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+	}
+
+	if err := checkPublicHost(context.Background(), "example.com"); err != nil {
+		t.Errorf("expected a public host, got %v", err)
+	}
+	if err := checkPublicHost(context.Background(), "internal.example.com"); err != ErrNonPublicWebhook {
+		t.Errorf("expected a host resolving to a private address not to be public, got %v", err)
+	}
+}
+
+func TestRepositoryEvents(t *testing.T) {
+	now := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+	published := now.Add(-time.Hour)
+
+	old := Repository{URL: "github.com/foo/bar"}
+	repo := Repository{
+		URL:      "github.com/foo/bar",
+		Archived: true,
+		Versions: []Version{
+			{Name: "v1.0.0"},
+			{Name: "v1.1.0", Published: published},
+			{Name: "v1.2.0", Draft: true},
+			{Name: "v1.3.0"},
+		},
+	}
+
+	events := repositoryEvents(old, []string{"v1.0.0"}, repo, now)
+	expected := []string{
+		"version:github.com/foo/bar@v1.1.0",
+		"version:github.com/foo/bar@v1.3.0",
+		"archived:github.com/foo/bar",
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+	for i, key := range expected {
+		if events[i].Key() != key {
+			t.Errorf("expected event %d to be %s, got %s", i, key, events[i].Key())
+		}
+	}
+	if !events[0].Time.Equal(published) || !events[1].Time.Equal(now) {
+		t.Errorf("expected versions to be dated by their publishing or now, got %v and %v", events[0].Time, events[1].Time)
+	}
+
+	old.Archived = true
+	if events := repositoryEvents(old, []string{"v1.0.0", "v1.1.0", "v1.3.0"}, repo, now); len(events) != 0 {
+		t.Errorf("expected no events for an unchanged repository, got %+v", events)
+	}
+}
+
+func TestDeliveryAttempted(t *testing.T) {
+	now := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+	d := Delivery{Status: DeliveryPending}
+
+	d = d.attempted(500, errors.New("unexpected status code from webhook: 500"), now)
+	if d.Status != DeliveryPending || d.Attempts != 1 || !d.NextAttempt.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected a failed delivery to be retried in a minute, got %+v", d)
+	}
+
+	d = d.attempted(200, nil, now)
+	if d.Status != DeliveryDelivered || d.Error != "" || d.ResponseStatus != 200 {
+		t.Errorf("expected the delivery to be delivered, got %+v", d)
+	}
+
+	d = Delivery{Attempts: maxDeliveryAttempts - 1}
+	if d = d.attempted(0, errors.New("timeout"), now); d.Status != DeliveryFailed {
+		t.Errorf("expected the delivery to fail after %d attempts, got %+v", maxDeliveryAttempts, d)
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{attempts: 1, backoff: time.Minute},
+		{attempts: 2, backoff: 2 * time.Minute},
+		{attempts: 5, backoff: 16 * time.Minute},
+		{attempts: 20, backoff: 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if backoff := deliveryBackoff(tt.attempts); backoff != tt.backoff {
+			t.Errorf("expected a backoff of %s after %d attempts, got %s", tt.backoff, tt.attempts, backoff)
+		}
+	}
+}
+
+func TestWebhookPayload(t *testing.T) {
+	d := Delivery{
+		Format: WebhookSlack,
+		Event:  Event{Type: EventArchived, Repository: "github.com/foo/bar"},
+	}
+
+	data, err := webhookPayload(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"text":"github.com/foo/bar was archived"}` {
+		t.Errorf("unexpected slack payload: %s", data)
+	}
+
+	d.Format = WebhookJSON
+	data, err = webhookPayload(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var e Event
+	if err := json.Unmarshal(data, &e); err != nil || e.Type != EventArchived || e.Repository != "github.com/foo/bar" {
+		t.Errorf("unexpected json payload: %s", data)
+	}
+}
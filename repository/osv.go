@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Advisory is a vulnerability of Go modules in the OSV format, see https://ossf.github.io/osv-schema/
+	Advisory struct {
+		ID         string             `json:"id"`
+		Summary    string             `json:"summary"`
+		Details    string             `json:"details"`
+		Aliases    []string           `json:"aliases"`
+		Published  time.Time          `json:"published"`
+		Modified   time.Time          `json:"modified"`
+		Withdrawn  *time.Time         `json:"withdrawn"`
+		Affected   []AdvisoryAffected `json:"affected"`
+		References []struct {
+			Type string `json:"type"`
+			URL  string `json:"url"`
+		} `json:"references"`
+	}
+	// AdvisoryAffected is a module and its versions affected by an Advisory
+	AdvisoryAffected struct {
+		Package struct {
+			Name      string `json:"name"`
+			Ecosystem string `json:"ecosystem"`
+		} `json:"package"`
+		Ranges   []AdvisoryRange `json:"ranges"`
+		Versions []string        `json:"versions"`
+	}
+	// AdvisoryRange is a range of affected versions described by events
+	AdvisoryRange struct {
+		Type   string          `json:"type"`
+		Events []AdvisoryEvent `json:"events"`
+	}
+	// AdvisoryEvent introduces or fixes an Advisory at a version
+	AdvisoryEvent struct {
+		Introduced   string `json:"introduced,omitempty"`
+		Fixed        string `json:"fixed,omitempty"`
+		LastAffected string `json:"last_affected,omitempty"`
+	}
+	// Vulnerability is an Advisory matching a module version
+	Vulnerability struct {
+		ID      string   `json:"id"`
+		Aliases []string `json:"aliases"`
+		Summary string   `json:"summary"`
+		URL     string   `json:"url"`
+		Module  string   `json:"module"`
+		Version string   `json:"version"`
+		Fixed   string   `json:"fixed,omitempty"`
+
+		Published time.Time `json:"published"`
+	}
+)
+
+// osvEcosystem is the ecosystem of Go modules in OSV advisories
+const osvEcosystem = "Go"
+
+// Modules returns the paths of all Go modules affected by an Advisory
+func (a Advisory) Modules() []string {
+	var modules []string
+	for _, af := range a.Affected {
+		if af.Package.Ecosystem == osvEcosystem {
+			modules = append(modules, af.Package.Name)
+		}
+	}
+	return modules
+}
+
+// URL returns the advisory's main reference
+func (a Advisory) URL() string {
+	for _, r := range a.References {
+		if r.Type == "ADVISORY" {
+			return r.URL
+		}
+	}
+	if len(a.References) > 0 {
+		return a.References[0].URL
+	}
+	return ""
+}
+
+// LoadAdvisories reads all OSV advisories of Go modules from a directory
+// or a zip file containing a json file per advisory.
+func LoadAdvisories(path string) ([]Advisory, error) {
+	var advisories []Advisory
+	add := func(name string, r io.Reader) error {
+		a, err := decodeAdvisory(r)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode advisory %s", name)
+		}
+		if a.Withdrawn == nil && len(a.Modules()) > 0 {
+			advisories = append(advisories, a)
+		}
+		return nil
+	}
+
+	if strings.HasSuffix(path, ".zip") {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		for _, f := range zr.File {
+			if !strings.HasSuffix(f.Name, ".json") {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			err = add(f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return advisories, nil
+	}
+
+	err := filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(name, ".json") {
+			return err
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return add(name, f)
+	})
+
+	return advisories, err
+}
+
+func decodeAdvisory(r io.Reader) (Advisory, error) {
+	var a Advisory
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return a, err
+	}
+	if err := json.Unmarshal(data, &a); err != nil {
+		return a, err
+	}
+	if a.ID == "" {
+		return a, errors.New("advisory has no id")
+	}
+	return a, nil
+}
+
+// affects returns true if a version of a module is affected by the range.
+// The events are applied in the order of their versions, as described by the OSV schema.
+func (r AdvisoryRange) affects(version semver) (bool, string) {
+	if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+		return false, ""
+	}
+
+	// compare compares an event's version to another version.
+	// Introduced at 0 is lower than any version, even pseudo-versions.
+	compare := func(e AdvisoryEvent, v semver) int {
+		if e.Introduced == "0" {
+			return -1
+		}
+		ev, _ := parseSemver(e.Introduced + e.Fixed + e.LastAffected)
+		return compareSemver(ev, v)
+	}
+	events := append([]AdvisoryEvent(nil), r.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[j].Introduced == "0" {
+			return false
+		}
+		ev, _ := parseSemver(events[j].Introduced + events[j].Fixed + events[j].LastAffected)
+		return compare(events[i], ev) < 0
+	})
+
+	affected, fixed := false, ""
+	for _, e := range events {
+		c := compare(e, version)
+		switch {
+		case e.Introduced != "" && c <= 0:
+			affected = true
+		case e.Fixed != "" && c <= 0:
+			affected = false
+		case e.Fixed != "" && affected && fixed == "":
+			fixed = "v" + strings.TrimPrefix(e.Fixed, "v")
+		case e.LastAffected != "" && c < 0:
+			affected = false
+		}
+	}
+
+	if !affected {
+		return false, ""
+	}
+	return true, fixed
+}
+
+// matchAdvisories returns the vulnerabilities of the advisories affecting any of the module versions
+func matchAdvisories(advisories []Advisory, modules []Dependency) []Vulnerability {
+	var vulns []Vulnerability
+	for _, m := range modules {
+		version, ok := parseSemver(m.Version)
+		if !ok {
+			continue
+		}
+
+		for _, a := range advisories {
+			for _, af := range a.Affected {
+				if af.Package.Ecosystem != osvEcosystem || af.Package.Name != m.Path {
+					continue
+				}
+
+				affected, fixed := false, ""
+				for _, v := range af.Versions {
+					if sv, ok := parseSemver(v); ok && compareSemver(sv, version) == 0 {
+						affected = true
+					}
+				}
+				for _, r := range af.Ranges {
+					if ok, f := r.affects(version); ok {
+						affected, fixed = true, f
+					}
+				}
+
+				if affected {
+					vulns = append(vulns, Vulnerability{
+						ID:      a.ID,
+						Aliases: a.Aliases,
+						Summary: a.Summary,
+						URL:     a.URL(),
+						Module:  m.Path,
+						Version: m.Version,
+						Fixed:   fixed,
+
+						Published: a.Published,
+					})
+					break
+				}
+			}
+		}
+	}
+
+	sort.Slice(vulns, func(i, j int) bool {
+		if vulns[i].Module != vulns[j].Module {
+			return vulns[i].Module < vulns[j].Module
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+
+	return vulns
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testAdvisory = `{
+  "id": "GO-2020-0001",
+  "modified": "2021-04-14T20:04:52Z",
+  "published": "2021-04-14T20:04:52Z",
+  "aliases": ["CVE-2020-36567"],
+  "summary": "Arbitrary log line injection in github.com/gin-gonic/gin",
+  "affected": [{
+    "package": {"name": "github.com/gin-gonic/gin", "ecosystem": "Go"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.6.0"}]}]
+  }],
+  "references": [
+    {"type": "FIX", "url": "https://github.com/gin-gonic/gin/pull/2237"},
+    {"type": "ADVISORY", "url": "https://pkg.go.dev/vuln/GO-2020-0001"}
+  ]
+}`
+
+func TestLoadAdvisories(t *testing.T) {
+	dir, err := ioutil.TempDir("", "advisories")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	withdrawn := `{"id": "GO-2020-0002", "withdrawn": "2021-01-01T00:00:00Z", "affected": [{"package": {"name": "github.com/foo/bar", "ecosystem": "Go"}}]}`
+	npm := `{"id": "GHSA-xxxx", "affected": [{"package": {"name": "left-pad", "ecosystem": "npm"}}]}`
+
+	files := map[string]string{"GO-2020-0001.json": testAdvisory, "GO-2020-0002.json": withdrawn, "GHSA-xxxx.json": npm}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zf, err := os.Create(filepath.Join(dir, "advisories.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	for name, content := range files {
+		w, err := zw.Create("osv/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	zf.Close()
+
+	for _, path := range []string{dir, filepath.Join(dir, "advisories.zip")} {
+		advisories, err := LoadAdvisories(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(advisories) != 1 || advisories[0].ID != "GO-2020-0001" {
+			t.Errorf("%s: expected only GO-2020-0001, got %+v", path, advisories)
+			continue
+		}
+		if url := advisories[0].URL(); url != "https://pkg.go.dev/vuln/GO-2020-0001" {
+			t.Errorf("%s: unexpected url %s", path, url)
+		}
+	}
+}
+
+func TestMatchAdvisories(t *testing.T) {
+	advisories := []Advisory{
+		{
+			ID: "GO-2020-0001",
+			Affected: []AdvisoryAffected{{
+				Ranges: []AdvisoryRange{{Type: "SEMVER", Events: []AdvisoryEvent{{Introduced: "0"}, {Fixed: "1.6.0"}}}},
+			}},
+		},
+		{
+			ID: "GO-2021-0002",
+			Affected: []AdvisoryAffected{{
+				Ranges: []AdvisoryRange{{Type: "SEMVER", Events: []AdvisoryEvent{
+					{Introduced: "1.2.0"}, {Fixed: "1.2.5"}, {Introduced: "1.3.0"}, {LastAffected: "1.3.1"},
+				}}},
+			}},
+		},
+	}
+	for i := range advisories {
+		advisories[i].Affected[0].Package.Name = "github.com/foo/bar"
+		advisories[i].Affected[0].Package.Ecosystem = "Go"
+	}
+
+	tests := []struct {
+		version string
+		ids     []string
+		fixed   string
+	}{
+		{version: "v1.0.0", ids: []string{"GO-2020-0001"}, fixed: "v1.6.0"},
+		{version: "v1.2.0", ids: []string{"GO-2020-0001", "GO-2021-0002"}, fixed: "v1.6.0"},
+		{version: "v1.2.5", ids: []string{"GO-2020-0001"}, fixed: "v1.6.0"},
+		{version: "v1.3.1", ids: []string{"GO-2020-0001", "GO-2021-0002"}, fixed: "v1.6.0"},
+		{version: "v1.3.2", ids: []string{"GO-2020-0001"}, fixed: "v1.6.0"},
+		{version: "v1.6.0", ids: nil},
+		{version: "v0.0.0-20190101000000-abcdef123456", ids: []string{"GO-2020-0001"}, fixed: "v1.6.0"},
+		{version: "master", ids: nil},
+	}
+
+	for _, tt := range tests {
+		vulns := matchAdvisories(advisories, []Dependency{
+			{Path: "github.com/foo/bar", Version: tt.version},
+			{Path: "github.com/foo/baz", Version: tt.version},
+		})
+		if len(vulns) != len(tt.ids) {
+			t.Errorf("%s: expected %v, got %+v", tt.version, tt.ids, vulns)
+			continue
+		}
+		for i, id := range tt.ids {
+			if vulns[i].ID != id || vulns[i].Module != "github.com/foo/bar" {
+				t.Errorf("%s: expected %s, got %+v", tt.version, id, vulns[i])
+			}
+		}
+		if len(vulns) > 0 && vulns[0].Fixed != tt.fixed {
+			t.Errorf("%s: expected fix in %s, got %s", tt.version, tt.fixed, vulns[0].Fixed)
+		}
+	}
+}
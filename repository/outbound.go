@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitOpen is returned for requests to a host whose circuit breaker is open,
+// as its latest requests failed. These requests aren't made at all.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// OutboundConfig configures the retries and circuit breakers of Outbound
+type OutboundConfig struct {
+	// Retries is the number of times a failed idempotent request is retried
+	Retries int
+	// Backoff is the base delay before the first retry, doubled for every further one
+	Backoff time.Duration
+	// Threshold is the number of consecutive failures opening a host's circuit breaker
+	Threshold int
+	// Cooldown is the time an open circuit breaker rejects requests before trying one again
+	Cooldown time.Duration
+}
+
+// Outbound is the http layer shared by the clients of all services godep requests,
+// like GitHub, godoc.org and the module proxy. Requests to a host are rejected right away
+// while its circuit breaker is open, instead of waiting for a service that is down.
+// Failed idempotent requests are retried with a jittered exponential backoff.
+type Outbound struct {
+	config       OutboundConfig
+	transport    http.RoundTripper
+	breakersOpen metrics.Gauge
+	retries      metrics.Counter
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewOutbound creates Outbound with the metrics of its open circuit breakers and retries
+func NewOutbound(config OutboundConfig, breakersOpen metrics.Gauge, retries metrics.Counter) *Outbound {
+	return &Outbound{
+		config:       config,
+		transport:    http.DefaultTransport,
+		breakersOpen: breakersOpen,
+		retries:      retries,
+		breakers:     map[string]*breaker{},
+	}
+}
+
+// Client returns a http client for a service, whose requests time out after timeout.
+// Only GET and HEAD requests are retried, unless all requests of the service are idempotent,
+// like GitHub's GraphQL queries, which are sent as POST.
+func (o *Outbound) Client(service string, timeout time.Duration, idempotent bool) *http.Client {
+	// Initialize metrics with a zero value
+	o.breakersOpen.With("service", service).Set(0)
+	o.retries.With("service", service).Add(0)
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &outboundTransport{
+			outbound:   o,
+			transport:  o.transport,
+			service:    service,
+			idempotent: idempotent,
+		},
+	}
+}
+
+// PublicClient returns a http client for a service like Client, which only connects to
+// public addresses. It's used for urls given by users, like the webhooks of subscriptions.
+func (o *Outbound) PublicClient(service string, timeout time.Duration) *http.Client {
+	client := o.Client(service, timeout, false)
+	client.Transport.(*outboundTransport).transport = &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   publicDialControl,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return client
+}
+
+type outboundTransport struct {
+	outbound   *Outbound
+	transport  http.RoundTripper
+	service    string
+	idempotent bool
+}
+
+func (t *outboundTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.outbound.roundTrip(t.transport, req, t.service, t.idempotent)
+}
+
+func (o *Outbound) roundTrip(transport http.RoundTripper, req *http.Request, service string, idempotent bool) (*http.Response, error) {
+	retry := idempotent || req.Method == http.MethodGet || req.Method == http.MethodHead
+	// Requests can only be sent again if their body can be read again
+	if req.Body != nil && req.GetBody == nil {
+		retry = false
+	}
+
+	// Retries send a clone of the request with a new body, as the caller's request must not be modified
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		if !o.allow(req.URL.Host) {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := transport.RoundTrip(attemptReq)
+		// Requests canceled by their caller don't say anything about the host
+		if req.Context().Err() != nil {
+			o.canceled(req.URL.Host)
+			return resp, err
+		}
+		failed := err != nil || retryStatus(resp.StatusCode)
+		o.record(service, req.URL.Host, failed)
+
+		if !failed || !retry || attempt >= o.config.Retries {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		attemptReq = req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to get request body to retry")
+			}
+			attemptReq.Body = body
+		}
+
+		o.retries.With("service", service).Add(1)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff(o.config.Backoff, attempt)):
+		}
+	}
+}
+
+// retryStatus returns if a response's status code means the host failed temporarily
+func retryStatus(code int) bool {
+	return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// backoff returns the delay before a retry, which is random between half and all of
+// the exponential backoff, so that clients don't retry in lockstep.
+func backoff(base time.Duration, attempt int) time.Duration {
+	d := base << uint(attempt)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// allow returns if a request to a host may be made
+func (o *Outbound) allow(host string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b, ok := o.breakers[host]
+	if !ok {
+		return true
+	}
+	return b.allow(time.Now(), o.config.Cooldown)
+}
+
+// record records the result of a request to a host and updates the metrics if its breaker opened or closed
+func (o *Outbound) record(service, host string, failed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b, ok := o.breakers[host]
+	if !ok {
+		if !failed {
+			return
+		}
+		b = &breaker{}
+		o.breakers[host] = b
+	}
+
+	wasOpen := b.open
+	b.record(failed, time.Now(), o.config.Threshold)
+	switch {
+	case b.open && !wasOpen:
+		o.breakersOpen.With("service", service).Add(1)
+	case !b.open && wasOpen:
+		o.breakersOpen.With("service", service).Add(-1)
+	}
+	if !b.open && b.failures == 0 {
+		delete(o.breakers, host)
+	}
+}
+
+// canceled lets another request try a host again, if the request trying it was canceled
+func (o *Outbound) canceled(host string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if b, ok := o.breakers[host]; ok {
+		b.probing = false
+	}
+}
+
+// breaker is the circuit breaker of a host. It opens after consecutive failures and rejects
+// all requests until the cooldown passed. Then it lets a single request through, which closes
+// the breaker again if it succeeds, or keeps it open for another cooldown if it fails.
+type breaker struct {
+	failures int
+	open     bool
+	opened   time.Time
+	probing  bool
+}
+
+func (b *breaker) allow(now time.Time, cooldown time.Duration) bool {
+	if !b.open {
+		return true
+	}
+	if b.probing || now.Sub(b.opened) < cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) record(failed bool, now time.Time, threshold int) {
+	b.probing = false
+	if !failed {
+		b.failures, b.open = 0, false
+		return
+	}
+
+	b.failures++
+	if b.open || b.failures >= threshold {
+		b.open, b.opened = true, now
+	}
+}
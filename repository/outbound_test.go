@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/pkg/errors"
+)
+
+func testOutbound(retries, threshold int, cooldown time.Duration) *Outbound {
+	return NewOutbound(OutboundConfig{
+		Retries:   retries,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}, discard.NewGauge(), discard.NewCounter())
+}
+
+// failingServer responds with 503 to the first failures requests
+func failingServer(failures int32) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return srv, &requests
+}
+
+func TestOutboundRetries(t *testing.T) {
+	tests := []struct {
+		method     string
+		idempotent bool
+		status     int
+		requests   int32
+	}{
+		{method: http.MethodGet, status: http.StatusOK, requests: 3},
+		{method: http.MethodPost, idempotent: true, status: http.StatusOK, requests: 3},
+		{method: http.MethodPost, status: http.StatusServiceUnavailable, requests: 1},
+	}
+
+	for _, test := range tests {
+		srv, requests := failingServer(2)
+
+		client := testOutbound(2, 10, time.Minute).Client("test", time.Second, test.idempotent)
+		req, _ := http.NewRequest(test.method, srv.URL, strings.NewReader("query"))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		srv.Close()
+
+		if resp.StatusCode != test.status || *requests != test.requests {
+			t.Errorf("%s (idempotent %v): expected %d after %d requests, got %d after %d requests",
+				test.method, test.idempotent, test.status, test.requests, resp.StatusCode, *requests)
+		}
+	}
+}
+
+func TestOutboundRetriesKeepRequest(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("query"))
+	body := req.Body
+	resp, err := testOutbound(2, 10, time.Minute).Client("test", time.Second, true).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(bodies) != 3 || bodies[1] != "query" || bodies[2] != "query" {
+		t.Errorf("expected the body to be sent with every retry, got %q", bodies)
+	}
+	if req.Body != body {
+		t.Error("expected the caller's request not to be modified")
+	}
+}
+
+func TestOutboundCircuitBreaker(t *testing.T) {
+	srv, requests := failingServer(3)
+	defer srv.Close()
+
+	o := testOutbound(0, 3, 50*time.Millisecond)
+	client := o.Client("test", time.Second, false)
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	_, err := client.Get(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), ErrCircuitOpen.Error()) {
+		t.Fatalf("expected the circuit breaker to be open, got %v", err)
+	}
+	if *requests != 3 {
+		t.Errorf("expected no request while the circuit breaker is open, got %d requests", *requests)
+	}
+	if errors.Cause(upstreamError("test", err)) != ErrUnavailable {
+		t.Errorf("expected an open circuit breaker to be unavailable, got %v", upstreamError("test", err))
+	}
+
+	// After the cooldown a request tries the host again and closes the breaker
+	time.Sleep(60 * time.Millisecond)
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(o.breakers) != 0 {
+		t.Errorf("expected the circuit breaker to be closed, got %+v", o.breakers)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := &breaker{}
+
+	b.record(true, now, 2)
+	if b.open {
+		t.Error("expected the breaker to stay closed below the threshold")
+	}
+	b.record(true, now, 2)
+	if !b.open || b.allow(now, time.Minute) {
+		t.Error("expected the breaker to open at the threshold")
+	}
+
+	later := now.Add(time.Minute)
+	if !b.allow(later, time.Minute) {
+		t.Error("expected the breaker to let a request through after the cooldown")
+	}
+	if b.allow(later, time.Minute) {
+		t.Error("expected the breaker to let only one request through after the cooldown")
+	}
+
+	b.record(true, later, 2)
+	if !b.open || b.allow(later.Add(time.Second), time.Minute) {
+		t.Error("expected the breaker to open for another cooldown after a failed request")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 4; attempt++ {
+		max := 100 * time.Millisecond << uint(attempt)
+		for i := 0; i < 100; i++ {
+			if d := backoff(100*time.Millisecond, attempt); d < max/2 || d > max {
+				t.Fatalf("expected the backoff of attempt %d to be between %s and %s, got %s", attempt, max/2, max, d)
+			}
+		}
+	}
+}
+
+func TestOutboundPublicClient(t *testing.T) {
+	srv, requests := failingServer(0)
+	defer srv.Close()
+
+	// The server listens on a loopback address, like an internal service would
+	_, err := testOutbound(0, 10, time.Minute).PublicClient("test", time.Second).Get(srv.URL)
+	if err == nil || !strings.Contains(err.Error(), ErrNonPublicWebhook.Error()) {
+		t.Errorf("expected the connection to a loopback address to be refused, got %v", err)
+	}
+	if *requests != 0 {
+		t.Errorf("expected no request, got %d", *requests)
+	}
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/pkg/errors"
+)
+
+// ModuleProxy requests modules from a Go module proxy like proxy.golang.org
+type ModuleProxy struct {
+	url      string
+	client   *http.Client
+	apiCalls metrics.Histogram
+}
+
+// NewModuleProxy initializes a ModuleProxy for the proxy at url
+func NewModuleProxy(url string, client *http.Client, apiCalls metrics.Histogram) (*ModuleProxy, error) {
+	mp := &ModuleProxy{
+		url:      strings.TrimSuffix(url, "/"),
+		client:   client,
+		apiCalls: apiCalls.With("service", "proxy"),
+	}
+
+	// Initialize metric with a zero value
+	mp.apiCalls.Observe(0)
+
+	return mp, nil
+}
+
+// maxZipSize is the largest module zip that is downloaded from the proxy
+const maxZipSize = 64 << 20
+
+// ErrZipTooLarge is returned when a module's zip exceeds maxZipSize
+var ErrZipTooLarge = errors.New("module zip is too large")
+
+// Info resolves a query like a tag to the canonical version of a module
+func (mp *ModuleProxy) Info(ctx context.Context, path, query string) (string, error) {
+	data, err := mp.get(ctx, path, query+".info")
+	if err != nil {
+		return "", err
+	}
+
+	var info struct{ Version string }
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", errors.Wrap(err, "failed to decode module info")
+	}
+
+	return info.Version, nil
+}
+
+// Zip returns the zip file with all files of a module's version
+func (mp *ModuleProxy) Zip(ctx context.Context, path, version string) (*zip.Reader, error) {
+	data, err := mp.get(ctx, path, version+".zip")
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipSize {
+		return nil, ErrZipTooLarge
+	}
+
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// Mod returns the go.mod file of a module's version
+func (mp *ModuleProxy) Mod(ctx context.Context, path, version string) ([]byte, error) {
+	return mp.get(ctx, path, version+".mod")
+}
+
+func (mp *ModuleProxy) get(ctx context.Context, path, file string) ([]byte, error) {
+	defer func(start time.Time) {
+		mp.apiCalls.Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	u := fmt.Sprintf("%s/%s/@v/%s", mp.url, escapeModulePath(path), escapeModulePath(file))
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+
+	resp, err := mp.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, upstreamError("the module proxy", errors.Wrap(err, "failed to do the request"))
+	}
+	defer resp.Body.Close()
+
+	// The proxy responds with 410 Gone for modules it won't ever serve
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("the module proxy", resp.StatusCode)
+	}
+
+	// Read one byte more than allowed to detect zips exceeding the limit
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxZipSize+1))
+}
+
+// escapeModulePath replaces every upper case letter with an exclamation mark
+// followed by the letter's lower case, as the module proxy protocol requires.
+func escapeModulePath(path string) string {
+	var b bytes.Buffer
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
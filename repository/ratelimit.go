@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+)
+
+// RateLimiter limits the rate of requests of each key, like a client's IP, with a token bucket.
+// Each bucket holds up to burst tokens and is refilled with burst tokens per period.
+type RateLimiter struct {
+	burst  float64
+	rate   float64 // tokens per second
+	mu     sync.Mutex
+	bucket map[string]*tokenBucket
+	pruned time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing burst requests per period for each key
+func NewRateLimiter(burst int, period time.Duration) *RateLimiter {
+	return &RateLimiter{
+		burst:  float64(burst),
+		rate:   float64(burst) / period.Seconds(),
+		bucket: map[string]*tokenBucket{},
+	}
+}
+
+// Allow takes a token from the bucket of a key.
+// If the bucket is empty it returns false and the time until the next token.
+func (l *RateLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+
+	b, ok := l.bucket[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.bucket[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// prune drops buckets that are refilled completely, at most once a minute,
+// as they are the same as new buckets.
+func (l *RateLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < time.Minute {
+		return
+	}
+	l.pruned = now
+
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.bucket {
+		if now.Sub(b.last) >= full {
+			delete(l.bucket, key)
+		}
+	}
+}
+
+// TrustedProxies are the networks of proxies whose X-Forwarded-For header is trusted
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a comma separated list of IPs and CIDR networks
+func ParseTrustedProxies(s string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse trusted proxy %s", p)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func (p TrustedProxies) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP of a request's client. Requests of trusted proxies are attributed to
+// the right-most address of their X-Forwarded-For header that isn't a trusted proxy itself,
+// as only trusted proxies append the address of their client reliably.
+func (p TrustedProxies) ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !p.trusted(ip) {
+		return ip
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		ip = hop
+		if !p.trusted(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// tooManyRequests responds with 429 and the seconds the client needs to wait
+func tooManyRequests(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", retryAfter(wait))
+	http.Error(w, "too many requests, try again later", http.StatusTooManyRequests)
+}
+
+// RateLimit is a middleware limiting the requests of each client
+func RateLimit(limiter *RateLimiter, proxies TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ok, wait := limiter.Allow(proxies.ClientIP(r), time.Now()); !ok {
+				tooManyRequests(w, wait)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// FetchRateLimit is a middleware limiting the requests of each client for repositories that
+// aren't indexed yet. These are fetched from GitHub, godoc.org and the module proxy,
+// so they get a much stricter limit than other requests.
+func FetchRateLimit(repositories Service, limiter *RateLimiter, proxies TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uri, err := githubURL(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Errors are left to the handler, which fails the same way
+			if indexed, err := repositories.Indexed(r.Context(), uri); err == nil && !indexed {
+				if ok, wait := limiter.Allow(proxies.ClientIP(r), time.Now()); !ok {
+					tooManyRequests(w, wait)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AnalysisRateLimit is a middleware limiting the requests of each client for versions of indexed
+// repositories that weren't analyzed yet. Analyzing a version downloads and reads its module's zip,
+// so these requests share the limit of FetchRateLimit. The versions are taken from the version
+// and versions url parameters and the version query, the current version is analyzed otherwise.
+func AnalysisRateLimit(repositories Service, limiter *RateLimiter, proxies TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uri, err := githubURL(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Repositories that aren't indexed are limited by FetchRateLimit already
+			if indexed, err := repositories.Indexed(r.Context(), uri); err != nil || !indexed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			versions := requestedVersions(r)
+			if len(versions) == 0 {
+				repo, err := repositories.Get(r.Context(), uri)
+				if err != nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+				versions = []string{repo.CurrentVersion.Name}
+			}
+
+			for _, v := range versions {
+				if _, err := repositories.StoredModuleVersion(r.Context(), uri, v); err != ErrNotFound {
+					continue
+				}
+				if ok, wait := limiter.Allow(proxies.ClientIP(r), time.Now()); !ok {
+					tooManyRequests(w, wait)
+					return
+				}
+				break
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestedVersions returns the versions a request is for, if it names any
+func requestedVersions(r *http.Request) []string {
+	var versions []string
+	if v := chi.URLParam(r, "version"); v != "" {
+		versions = append(versions, v)
+	}
+	for _, v := range strings.SplitN(chi.URLParam(r, "versions"), "...", 2) {
+		if v != "" {
+			versions = append(versions, v)
+		}
+	}
+	if v := r.URL.Query().Get("version"); v != "" {
+		versions = append(versions, v)
+	}
+	return versions
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("127.0.0.1", now); !ok {
+			t.Fatalf("expected request %d to be allowed", i)
+		}
+	}
+	ok, wait := l.Allow("127.0.0.1", now)
+	if ok || wait != 30*time.Second {
+		t.Errorf("expected the third request to wait 30s, got %v and %s", ok, wait)
+	}
+	if ok, _ := l.Allow("127.0.0.2", now); !ok {
+		t.Error("expected another client to be allowed")
+	}
+	if ok, _ := l.Allow("127.0.0.1", now.Add(30*time.Second)); !ok {
+		t.Error("expected a request to be allowed after a token was refilled")
+	}
+}
+
+func TestTrustedProxiesClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		remote    string
+		forwarded string
+		ip        string
+	}{
+		{remote: "203.0.113.1:1234", forwarded: "", ip: "203.0.113.1"},
+		// Only trusted proxies can forward the client's address
+		{remote: "203.0.113.1:1234", forwarded: "198.51.100.1", ip: "203.0.113.1"},
+		{remote: "10.0.0.1:1234", forwarded: "198.51.100.1", ip: "198.51.100.1"},
+		{remote: "10.0.0.1:1234", forwarded: "198.51.100.2, 198.51.100.1, 192.168.1.1", ip: "198.51.100.1"},
+		{remote: "10.0.0.1:1234", forwarded: "", ip: "10.0.0.1"},
+		{remote: "10.0.0.1:1234", forwarded: "unknown, 10.0.0.2", ip: "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if ip := proxies.ClientIP(r); ip != tt.ip {
+			t.Errorf("expected client ip %s for %s forwarding %q, got %s", tt.ip, tt.remote, tt.forwarded, ip)
+		}
+	}
+
+	if _, err := ParseTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("expected an invalid network to fail")
+	}
+}
+
+type indexedService struct {
+	Service
+	indexed map[string]bool
+}
+
+func (s indexedService) Indexed(ctx context.Context, url string) (bool, error) {
+	return s.indexed[url], nil
+}
+
+func TestFetchRateLimit(t *testing.T) {
+	repositories := indexedService{indexed: map[string]bool{"github.com/foo/bar": true}}
+
+	r := chi.NewRouter()
+	r.Use(RateLimit(NewRateLimiter(5, time.Minute), nil))
+	r.Group(func(r chi.Router) {
+		r.Use(FetchRateLimit(repositories, NewRateLimiter(1, time.Hour), nil))
+		r.Get("/github.com/{owner}/{name}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{url: "/github.com/foo/new", status: http.StatusOK},
+		{url: "/github.com/foo/other", status: http.StatusTooManyRequests},
+		{url: "/github.com/foo/bar", status: http.StatusOK},
+		{url: "/github.com/foo/bar", status: http.StatusOK},
+		{url: "/github.com/foo/bar", status: http.StatusOK},
+		// The general limit applies to indexed repositories too
+		{url: "/github.com/foo/bar", status: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("expected status %d for %s, got %d", tt.status, tt.url, w.Code)
+		}
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("expected a Retry-After header for %s", tt.url)
+		}
+	}
+}
+
+type analyzedService struct {
+	indexedService
+	analyzed map[string]bool
+}
+
+func (s analyzedService) Get(ctx context.Context, url string) (Repository, error) {
+	return Repository{URL: url, CurrentVersion: Version{Name: "v1.2.0"}}, nil
+}
+
+func (s analyzedService) StoredModuleVersion(ctx context.Context, url string, version string) (ModuleVersion, error) {
+	if !s.analyzed[url+"@"+version] {
+		return ModuleVersion{}, ErrNotFound
+	}
+	return ModuleVersion{}, nil
+}
+
+func TestAnalysisRateLimit(t *testing.T) {
+	repositories := analyzedService{
+		indexedService: indexedService{indexed: map[string]bool{"github.com/foo/bar": true}},
+		analyzed: map[string]bool{
+			"github.com/foo/bar@v1.0.0": true,
+			"github.com/foo/bar@v1.2.0": true,
+		},
+	}
+
+	r := chi.NewRouter()
+	// The url parameters are only known to middlewares of routes
+	r.Group(func(r chi.Router) {
+		r.Use(AnalysisRateLimit(repositories, NewRateLimiter(1, time.Hour), nil))
+		handler := func(w http.ResponseWriter, r *http.Request) {}
+		r.Get("/github.com/{owner}/{name}/@{version}", handler)
+		r.Get("/github.com/{owner}/{name}/compare/{versions}", handler)
+		r.Get("/github.com/{owner}/{name}/graph", handler)
+	})
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{url: "/github.com/foo/bar/@v1.0.0", status: http.StatusOK},
+		{url: "/github.com/foo/bar/graph", status: http.StatusOK},
+		{url: "/github.com/foo/bar/@v1.1.0", status: http.StatusOK},
+		// The client analyzed too many versions
+		{url: "/github.com/foo/bar/compare/v1.0.0...v1.1.0", status: http.StatusTooManyRequests},
+		{url: "/github.com/foo/bar/graph?version=v1.1.0", status: http.StatusTooManyRequests},
+		{url: "/github.com/foo/bar/compare/v1.0.0...v1.2.0", status: http.StatusOK},
+		// Repositories that aren't indexed are limited by FetchRateLimit
+		{url: "/github.com/foo/new/@v1.0.0", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("expected status %d for %s, got %d", tt.status, tt.url, w.Code)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RefreshState is the state of a repository's refresh
+type RefreshState string
+
+// States of refreshes
+const (
+	RefreshQueued   RefreshState = "queued"
+	RefreshRunning  RefreshState = "running"
+	RefreshFinished RefreshState = "finished"
+	RefreshFailed   RefreshState = "failed"
+)
+
+// RefreshStatus is the status of a repository's latest refresh
+type RefreshStatus struct {
+	URL      string       `json:"url"`
+	State    RefreshState `json:"state"`
+	Queued   time.Time    `json:"queued"`
+	Finished time.Time    `json:"finished,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// Pending returns if the refresh is queued or running
+func (s RefreshStatus) Pending() bool {
+	return s.State == RefreshQueued || s.State == RefreshRunning
+}
+
+const (
+	// refreshCooldown is the time a repository can't be refreshed manually after its last refresh was queued
+	refreshCooldown = 5 * time.Minute
+	// refreshStatusTTL is the time the status of a finished refresh is kept
+	refreshStatusTTL = time.Hour
+)
+
+// RefreshQueue queues repositories to be refreshed in the background.
+// Repositories already waiting in the queue aren't queued twice.
+type RefreshQueue struct {
+	queue chan string
+
+	mu     sync.Mutex
+	status map[string]RefreshStatus
+}
+
+// NewRefreshQueue creates a RefreshQueue holding up to size repositories
+func NewRefreshQueue(size int) *RefreshQueue {
+	return &RefreshQueue{
+		queue:  make(chan string, size),
+		status: map[string]RefreshStatus{},
+	}
+}
+
+// Enqueue queues a repository to be refreshed.
+// It returns false if the queue is full.
+func (q *RefreshQueue) Enqueue(url string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.status[url].State == RefreshQueued {
+		return true
+	}
+
+	select {
+	case q.queue <- url:
+		q.prune(time.Now())
+		q.status[url] = RefreshStatus{URL: url, State: RefreshQueued, Queued: time.Now()}
+		return true
+	default:
+		return false
+	}
+}
+
+// prune forgets the status of refreshes that finished a while ago
+func (q *RefreshQueue) prune(now time.Time) {
+	for url, s := range q.status {
+		if !s.Pending() && now.Sub(s.Finished) > refreshStatusTTL {
+			delete(q.status, url)
+		}
+	}
+}
+
+// Status returns the status of a repository's latest refresh,
+// or false if it wasn't refreshed recently.
+func (q *RefreshQueue) Status(url string) (RefreshStatus, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s, ok := q.status[url]
+	return s, ok
+}
+
+// Next waits for the next repository to refresh.
+// It returns false once the context is done.
+func (q *RefreshQueue) Next(ctx context.Context) (string, bool) {
+	// select picks randomly if a repository is queued as well
+	if ctx.Err() != nil {
+		return "", false
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", false
+	case url := <-q.queue:
+		q.mu.Lock()
+		s := q.status[url]
+		s.State = RefreshRunning
+		q.status[url] = s
+		q.mu.Unlock()
+		return url, true
+	}
+}
+
+// Done records the result of a repository's refresh
+func (q *RefreshQueue) Done(url string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	s := q.status[url]
+	// The repository was queued again while it was refreshed
+	if s.State == RefreshQueued {
+		return
+	}
+
+	s.State, s.Finished, s.Error = RefreshFinished, time.Now(), ""
+	if err != nil {
+		s.State, s.Error = RefreshFailed, err.Error()
+	}
+	q.status[url] = s
+}
+
+// RefreshHandler queues a repository to be refreshed and responds with the refresh's status.
+// Only repositories that are indexed already can be refreshed, at most every few minutes,
+// and clients are limited by their IP. Repositories that aren't indexed are fetched
+// by requesting their page, which is limited by FetchRateLimit.
+// Forms are redirected back to the repository's page, other clients get the status as json.
+func RefreshHandler(repositories Service, refreshes *RefreshQueue, limiter *RateLimiter, proxies TrustedProxies) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uri, err := githubURL(r)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		respond := func(status int, s RefreshStatus) {
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+				http.Redirect(w, r, "/"+uri, http.StatusSeeOther)
+				return
+			}
+			writeJSON(w, status, s)
+		}
+
+		indexed, err := repositories.Indexed(r.Context(), uri)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "failed to look up repository")
+			return
+		}
+		if !indexed {
+			writeJSONError(w, http.StatusNotFound, "repository isn't indexed yet, request its page first")
+			return
+		}
+
+		if s, ok := refreshes.Status(uri); ok && (s.Pending() || time.Since(s.Queued) < refreshCooldown) {
+			w.Header().Set("Retry-After", retryAfter(refreshCooldown-time.Since(s.Queued)))
+			respond(http.StatusTooManyRequests, s)
+			return
+		}
+
+		if ok, wait := limiter.Allow(proxies.ClientIP(r), time.Now()); !ok {
+			w.Header().Set("Retry-After", retryAfter(wait))
+			writeJSONError(w, http.StatusTooManyRequests, "too many refreshes, try again later")
+			return
+		}
+
+		if !refreshes.Enqueue(uri) {
+			writeJSONError(w, http.StatusServiceUnavailable, "too many repositories queued to be refreshed")
+			return
+		}
+
+		s, _ := refreshes.Status(uri)
+		respond(http.StatusAccepted, s)
+	}
+}
+
+// retryAfter formats a duration as seconds for the Retry-After header, rounding up
+func retryAfter(d time.Duration) string {
+	if d < time.Second {
+		d = time.Second
+	}
+	return fmt.Sprintf("%d", int((d+time.Second-1)/time.Second))
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+func TestRefreshQueue(t *testing.T) {
+	q := NewRefreshQueue(2)
+
+	if !q.Enqueue("github.com/foo/bar") || !q.Enqueue("github.com/foo/bar") || !q.Enqueue("github.com/foo/baz") {
+		t.Fatal("expected repositories to be queued")
+	}
+	if q.Enqueue("github.com/foo/qux") {
+		t.Error("expected the queue to be full")
+	}
+
+	url, ok := q.Next(context.Background())
+	if !ok || url != "github.com/foo/bar" {
+		t.Fatalf("expected github.com/foo/bar to be refreshed next, got %s", url)
+	}
+	if s, _ := q.Status(url); s.State != RefreshRunning {
+		t.Errorf("expected the refresh to be running, got %s", s.State)
+	}
+	q.Done(url, errors.New("failed to fetch"))
+	if s, _ := q.Status(url); s.State != RefreshFailed || s.Error != "failed to fetch" {
+		t.Errorf("expected the refresh to have failed, got %+v", s)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.Next(ctx)
+	if _, ok := q.Next(ctx); ok {
+		t.Error("expected no repository once the context is done")
+	}
+}
+
+func TestRefreshHandler(t *testing.T) {
+	repositories := indexedService{indexed: map[string]bool{
+		"github.com/foo/bar": true,
+		"github.com/foo/baz": true,
+		"github.com/foo/qux": true,
+	}}
+	r := chi.NewRouter()
+	r.Post("/github.com/{owner}/{name}/refresh", RefreshHandler(repositories, NewRefreshQueue(10), NewRateLimiter(2, time.Hour), nil))
+
+	tests := []struct {
+		url    string
+		status int
+	}{
+		// Repositories that aren't indexed can't be refreshed
+		{url: "/github.com/foo/new/refresh", status: http.StatusNotFound},
+		{url: "/github.com/foo/bar/refresh", status: http.StatusAccepted},
+		// The repository was refreshed just now
+		{url: "/github.com/foo/bar/refresh", status: http.StatusTooManyRequests},
+		{url: "/github.com/foo/baz/refresh", status: http.StatusAccepted},
+		// The client refreshed too many repositories
+		{url: "/github.com/foo/qux/refresh", status: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.url, nil))
+
+		if w.Code != tt.status {
+			t.Errorf("expected status %d for %s, got %d", tt.status, tt.url, w.Code)
+		}
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("expected a Retry-After header for %s", tt.url)
+		}
+	}
+}
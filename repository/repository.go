@@ -7,31 +7,83 @@ import (
 type (
 	// Repository is a software repository containing Go code.
 	Repository struct {
-		URL         string
-		Description string
-		Updated     time.Time
+		URL         string    `json:"url"`
+		Description string    `json:"description"`
+		Created     time.Time `json:"created"`
+		Updated     time.Time `json:"updated"`
+		Pushed      time.Time `json:"pushed"`
+		Archived    bool      `json:"archived"`
+		// Incomplete lists the services that failed when the repository was fetched,
+		// the repository lacks their data until it's refreshed.
+		Incomplete []string `json:"incomplete,omitempty"`
 
-		CurrentVersion Version
-		License        License
-		Statistics     []Statistic
-		Topics         []Topic
-		Versions       []Version
+		CurrentVersion Version     `json:"current_version"`
+		License        License     `json:"license"`
+		Modules        []Module    `json:"modules"`
+		Statistics     []Statistic `json:"statistics"`
+		Topics         []Topic     `json:"topics"`
+		Versions       []Version   `json:"versions"`
+		VersionsCount  int         `json:"versions_count"`
+
+		Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+		Health          Health          `json:"health"`
+		Status          Status          `json:"status"`
 	}
-	// License of a Repository
+	// License of a Repository, identified by its SPDX ID
 	License struct {
-		Name string
+		Name string `json:"name"`
+		SPDX string `json:"spdx"`
+		URL  string `json:"url"`
+	}
+	// Module is the Go module of a Repository's major version.
+	// Path is empty if the major version has no go.mod.
+	Module struct {
+		Major   int    `json:"major"`
+		Path    string `json:"path"`
+		Version string `json:"version"`
+	}
+	// ModuleVersion is the content of a Module at one of its versions.
+	// BuildList contains all modules selected for a build, once resolved,
+	// Licenses the licenses detected in its license files.
+	ModuleVersion struct {
+		Path              string
+		Version           string
+		GoMod             string
+		Dependencies      []Dependency
+		BuildList         []Dependency
+		BuildListResolved bool
+		Packages          []Package
+		LicenseFiles      []string
+		Licenses          []DetectedLicense
+		LicensesDetected  bool
+		Tests             bool
+		Vulnerabilities   []Vulnerability
+	}
+	// Dependency is a module required by a ModuleVersion.
+	// Replace is the module replacing it in a build list, if it's replaced by the main module.
+	Dependency struct {
+		Path     string
+		Version  string
+		Indirect bool
+		Replace  string
 	}
 	// Statistic of a Repository
 	Statistic struct {
-		Name  string
-		Value int
-		URL   string
+		Name  string `json:"name"`
+		Value int    `json:"value"`
+		URL   string `json:"url"`
 	}
 	// Topic describing a Repository
 	Topic struct{}
 	// Version a Repository was tagged with
 	Version struct {
-		Name      string
-		Published time.Time
+		Name       string    `json:"name"`
+		Published  time.Time `json:"published"`
+		Prerelease bool      `json:"prerelease"`
+		Draft      bool      `json:"draft"`
+		Breaking   bool      `json:"breaking"`
+		Retracted  bool      `json:"retracted"`
+		Retraction string    `json:"retraction,omitempty"`
+		Notes      string    `json:"notes,omitempty"`
 	}
 )
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version as described on semver.org
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+	build               string
+}
+
+// parseSemver parses a tag like v1.2.3-rc.1+build into a semver.
+// The leading v as well as the minor and patch versions are optional,
+// as tags like v1.0 or 2 are commonly used as well.
+func parseSemver(s string) (semver, bool) {
+	var v semver
+
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		v.build = s[i+1:]
+		s = s[:i]
+		if v.build == "" {
+			return v, false
+		}
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.prerelease = s[i+1:]
+		s = s[:i]
+		if v.prerelease == "" {
+			return v, false
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+
+	numbers := [3]int{}
+	for i, p := range parts {
+		if p == "" || (len(p) > 1 && p[0] == '0') {
+			return v, false
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		numbers[i] = n
+	}
+	v.major, v.minor, v.patch = numbers[0], numbers[1], numbers[2]
+
+	return v, true
+}
+
+// String returns the canonical representation of a semver, like v1.2.3-rc.1
+func (v semver) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.major, v.minor, v.patch)
+	if v.prerelease != "" {
+		s += "-" + v.prerelease
+	}
+	if v.build != "" {
+		s += "+" + v.build
+	}
+	return s
+}
+
+// compareSemver returns -1 if a has a lower precedence than b,
+// 1 if a has a higher precedence than b and 0 if they are equal.
+func compareSemver(a, b semver) int {
+	if c := compareInt(a.major, b.major); c != 0 {
+		return c
+	}
+	if c := compareInt(a.minor, b.minor); c != 0 {
+		return c
+	}
+	if c := compareInt(a.patch, b.patch); c != 0 {
+		return c
+	}
+
+	// A version without a prerelease has a higher precedence
+	if a.prerelease == "" || b.prerelease == "" {
+		if a.prerelease == b.prerelease {
+			return 0
+		}
+		if a.prerelease == "" {
+			return 1
+		}
+		return -1
+	}
+
+	as, bs := strings.Split(a.prerelease, "."), strings.Split(b.prerelease, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			// Numeric identifiers have a lower precedence than alphanumeric ones
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return compareInt(len(as), len(bs))
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// sortVersions sorts versions by their precedence, the lowest first.
+// Versions that aren't semantic versions are sorted before all others
+// by their publish date as their precedence can't be determined.
+// Versions with a prerelease suffix are flagged as Prerelease.
+func sortVersions(versions []Version) {
+	parsed := make(map[string]semver, len(versions))
+	for i, v := range versions {
+		sv, ok := parseSemver(v.Name)
+		if !ok {
+			continue
+		}
+		parsed[v.Name] = sv
+		if sv.prerelease != "" {
+			versions[i].Prerelease = true
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, aok := parsed[versions[i].Name]
+		b, bok := parsed[versions[j].Name]
+
+		switch {
+		case aok && bok:
+			if c := compareSemver(a, b); c != 0 {
+				return c < 0
+			}
+			return versions[i].Name < versions[j].Name
+		case aok != bok:
+			return bok
+		default:
+			return versions[i].Published.Before(versions[j].Published)
+		}
+	})
+}
+
+// currentVersion returns the version that should be used by default.
+// The versions are expected to be sorted with the highest precedence first.
+// Drafts, prereleases and retracted versions are never considered to be the current version.
+func currentVersion(versions []Version) Version {
+	for _, v := range versions {
+		if v.Draft || v.Prerelease || v.Retracted {
+			continue
+		}
+		if _, ok := parseSemver(v.Name); ok {
+			return v
+		}
+	}
+
+	// Fall back to the latest stable version that's no semantic version
+	for _, v := range versions {
+		if !v.Draft && !v.Prerelease && !v.Retracted {
+			return v
+		}
+	}
+
+	return Version{}
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSemver(t *testing.T) {
+	versions := map[string]struct {
+		semver semver
+		ok     bool
+	}{
+		"v1.2.3":            {semver{major: 1, minor: 2, patch: 3}, true},
+		"1.2.3":             {semver{major: 1, minor: 2, patch: 3}, true},
+		"v1.0":              {semver{major: 1}, true},
+		"v2.0.0-rc1":        {semver{major: 2, prerelease: "rc1"}, true},
+		"v1.0.0-beta.2+abc": {semver{major: 1, prerelease: "beta.2", build: "abc"}, true},
+		"weekly.2011-12-22": {ok: false},
+		"v01.2.3":           {ok: false},
+		"v1.2.3.4":          {ok: false},
+		"v1.2.3-":           {ok: false},
+		"release":           {ok: false},
+	}
+
+	for s, expected := range versions {
+		v, ok := parseSemver(s)
+		if ok != expected.ok {
+			t.Errorf("%s: expected ok to be %t", s, expected.ok)
+			continue
+		}
+		if ok && v != expected.semver {
+			t.Errorf("%s: expected %+v, got %+v", s, expected.semver, v)
+		}
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	// Every version has a lower precedence than the following one
+	ordered := []string{
+		"v1.0.0-alpha",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta",
+		"v1.0.0-beta.2",
+		"v1.0.0-beta.11",
+		"v1.0.0-rc.1",
+		"v1.0.0",
+		"v1.9.0",
+		"v1.10.0",
+		"v2.0.0-rc1",
+		"v2.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		a, _ := parseSemver(ordered[i])
+		b, _ := parseSemver(ordered[i+1])
+		if compareSemver(a, b) != -1 || compareSemver(b, a) != 1 {
+			t.Errorf("expected %s to have a lower precedence than %s", ordered[i], ordered[i+1])
+		}
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	versions := []Version{
+		{Name: "v1.10.0"},
+		{Name: "weekly.2011-12-22", Published: time.Date(2011, 12, 22, 0, 0, 0, 0, time.UTC)},
+		{Name: "v2.0.0-rc1"},
+		{Name: "v1.9.0"},
+		{Name: "weekly.2011-11-01", Published: time.Date(2011, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	sortVersions(versions)
+
+	expected := []string{"weekly.2011-11-01", "weekly.2011-12-22", "v1.9.0", "v1.10.0", "v2.0.0-rc1"}
+	for i, name := range expected {
+		if versions[i].Name != name {
+			t.Errorf("expected %s at position %d, got %s", name, i, versions[i].Name)
+		}
+	}
+	if !versions[4].Prerelease {
+		t.Errorf("expected %s to be flagged as prerelease", versions[4].Name)
+	}
+}
+
+func TestCurrentVersion(t *testing.T) {
+	versions := []Version{
+		{Name: "v2.0.0-rc1", Prerelease: true},
+		{Name: "v1.11.0", Draft: true},
+		{Name: "v1.10.1", Retracted: true},
+		{Name: "v1.10.0"},
+		{Name: "weekly.2011-12-22"},
+	}
+
+	if v := currentVersion(versions); v.Name != "v1.10.0" {
+		t.Errorf("expected v1.10.0 to be the current version, got %s", v.Name)
+	}
+	if v := currentVersion(versions[:3]); v.Name != "" {
+		t.Errorf("expected no current version, got %s", v.Name)
+	}
+	if v := currentVersion(versions[4:]); v.Name != "weekly.2011-12-22" {
+		t.Errorf("expected weekly.2011-12-22 to be the current version, got %s", v.Name)
+	}
+}
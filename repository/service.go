@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"fmt"
+	"go/types"
+	"sort"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -12,17 +15,73 @@ type (
 	// actual business logic for repositories.
 	Service interface {
 		Get(ctx context.Context, url string) (Repository, error)
+		Indexed(ctx context.Context, url string) (bool, error)
 		Homepage(ctx context.Context) (Homepage, error)
+		Versions(ctx context.Context, url string, page int) (VersionList, error)
+		ModuleVersion(ctx context.Context, url string, version string) (ModuleVersion, error)
+		StoredModuleVersion(ctx context.Context, url string, version string) (ModuleVersion, error)
+		Analyze(ctx context.Context, url string) error
+		Compare(ctx context.Context, url string, from, to string) (Comparison, error)
+		Compatibility(ctx context.Context, url string, from, to string) (Compatibility, error)
+		StoredCompatibility(ctx context.Context, url string, from, to string) (Compatibility, error)
+		CheckVersions(ctx context.Context) error
+		Graph(ctx context.Context, url string, version string, depth int) (Graph, error)
+		ImportAdvisories(ctx context.Context, advisories []Advisory) error
+		Licenses(ctx context.Context, url string, version string) (LicenseReport, error)
+		ReleaseFeed(ctx context.Context, url string) (Feed, error)
+		IndexFeed(ctx context.Context) (Feed, error)
+		Subscribe(ctx context.Context, sub Subscription) (Subscription, error)
+		Subscription(ctx context.Context, id string) (Subscription, error)
+		Unsubscribe(ctx context.Context, id string) error
+		Refresh(ctx context.Context, url string) error
+		CheckSubscriptions(ctx context.Context) error
+		Notify(ctx context.Context) error
 	}
 	// Storage is an interface which implementation should actually
 	// store and retrieve repositories.
 	Storage interface {
 		Get(ctx context.Context, url string) (Repository, error)
+		GetVersions(ctx context.Context, url string, limit, offset int) ([]Version, int, error)
+		GetVersion(ctx context.Context, url string, name string) (Version, error)
+		GetVersionRange(ctx context.Context, url string, from, to string) ([]Version, error)
+		GetReleases(ctx context.Context, url string, limit int) ([]Release, error)
+		GetUncheckedVersions(ctx context.Context, before time.Time, limit int) ([]VersionCheck, error)
+		SetCheckAttempt(ctx context.Context, url string, name string, a Attempt) error
+		GetAnalysisAttempt(ctx context.Context, url string, name string) (Attempt, error)
+		SetAnalysisAttempt(ctx context.Context, url string, name string, a Attempt) error
+		SetVersionBreaking(ctx context.Context, url string, name string, breaking bool) error
+		GetCompatibility(ctx context.Context, url string, from, to string) (Compatibility, error)
+		CreateCompatibility(ctx context.Context, url string, c Compatibility) error
+		GetModuleVersion(ctx context.Context, path, version string) (ModuleVersion, error)
+		CreateModuleVersion(ctx context.Context, mv ModuleVersion) error
+		SetBuildList(ctx context.Context, path, version string, list []Dependency) error
+		GetModuleLicenses(ctx context.Context, modules []Dependency) (map[string][]DetectedLicense, error)
+		SetModuleLicenses(ctx context.Context, path, version string, licenses []DetectedLicense) error
+		GetGoMod(ctx context.Context, path, version string) (string, error)
+		CreateGoMod(ctx context.Context, path, version, gomod string) error
+		GetAdvisories(ctx context.Context, paths []string) ([]Advisory, error)
+		CreateAdvisories(ctx context.Context, advisories []Advisory) error
+		GetLicenses(ctx context.Context, urls []string) (map[string]License, error)
 		GetPopular(ctx context.Context, limit int) ([]string, error)
 		GetLatest(ctx context.Context, limit int) ([]string, error)
+		GetNewest(ctx context.Context, limit int) ([]Repository, error)
+		GetHealthiest(ctx context.Context, limit int) ([]string, error)
+		SetHealth(ctx context.Context, url string, health Health) error
+		GetReleaseActivity(ctx context.Context, url string, since time.Time) (ReleaseActivity, error)
 		GetRandom(ctx context.Context, limit int) ([]string, error)
 		Exists(ctx context.Context, url string) (bool, error)
 		Create(ctx context.Context, repo Repository) error
+		Update(ctx context.Context, repo Repository) error
+		GetMissing(ctx context.Context, url string) (time.Time, error)
+		SetMissing(ctx context.Context, url string, checked time.Time) error
+		CreateSubscription(ctx context.Context, sub Subscription) (Subscription, error)
+		GetSubscription(ctx context.Context, id string) (Subscription, error)
+		DeleteSubscription(ctx context.Context, id string) error
+		GetWatched(ctx context.Context, before time.Time, limit int) ([]string, error)
+		SetWatchChecked(ctx context.Context, url string, checked time.Time) error
+		CreateDeliveries(ctx context.Context, events []Event) error
+		GetPendingDeliveries(ctx context.Context, limit int) ([]Delivery, error)
+		UpdateDelivery(ctx context.Context, d Delivery) error
 	}
 )
 
@@ -30,17 +89,31 @@ type (
 var ErrNotFound = errors.New("repository not found")
 
 type service struct {
-	github       *GitHub
-	godoc        *GoDoc
-	repositories Storage
+	github        *GitHub
+	godoc         *GoDoc
+	proxy         *ModuleProxy
+	webhooks      *Webhooks
+	repositories  Storage
+	licensePolicy LicensePolicy
+	inactivity    time.Duration
+	missing       *missingCache
+	homepage      *homepageCache
 }
 
 // NewService creates a new Service implementation which works with a Storage.
-func NewService(repositories Storage, gh *GitHub, gd *GoDoc) Service {
+// The licenses of dependencies are checked against the LicensePolicy,
+// repositories without any commits for the inactivity period are considered inactive.
+func NewService(repositories Storage, gh *GitHub, gd *GoDoc, mp *ModuleProxy, wh *Webhooks, policy LicensePolicy, inactivity time.Duration) Service {
 	return &service{
-		github:       gh,
-		godoc:        gd,
-		repositories: repositories,
+		github:        gh,
+		godoc:         gd,
+		proxy:         mp,
+		webhooks:      wh,
+		repositories:  repositories,
+		licensePolicy: policy,
+		inactivity:    inactivity,
+		missing:       newMissingCache(missingTTL),
+		homepage:      newHomepageCache(),
 	}
 }
 
@@ -51,76 +124,977 @@ func (s *service) Get(ctx context.Context, url string) (Repository, error) {
 	}
 
 	if !exists {
-		godocInfo, err := s.godoc.Get(ctx, url)
+		repo, err := s.fetchMissing(ctx, url)
 		if err != nil {
-			return Repository{}, err
+			return repo, err
+		}
+		if err := s.repositories.Create(ctx, repo); err != nil {
+			return repo, err
+		}
+	}
+
+	repo, err := s.repositories.Get(ctx, url)
+	if err != nil {
+		return repo, err
+	}
+
+	repo.Vulnerabilities, err = s.repositoryVulnerabilities(ctx, repo)
+	if err != nil {
+		return repo, err
+	}
+
+	repo.Status = s.status(ctx, repo)
+
+	if time.Since(repo.Health.Computed) > healthTTL {
+		var mv ModuleVersion
+		if path, version, ok := canonicalVersion(repo, repo.CurrentVersion.Name); ok {
+			// The current version is only judged once it was analyzed
+			mv, err = s.repositories.GetModuleVersion(ctx, path, version)
+			if err == ErrNotFound {
+				mv = ModuleVersion{}
+			} else if err != nil {
+				return repo, err
+			}
 		}
 
-		repo, err := s.github.Get(ctx, url)
+		now := time.Now()
+		releases, err := s.repositories.GetReleaseActivity(ctx, repo.URL, now.Add(-365*day))
 		if err != nil {
 			return repo, err
 		}
 
-		if godocInfo.Imports > 0 {
-			repo.Statistics = append(repo.Statistics, Statistic{
-				Name:  "Imports",
-				Value: godocInfo.Imports,
-				URL:   fmt.Sprintf("https://godoc.org/%s?imports", repo.URL),
-			})
+		repo.Health = computeHealth(repo, releases, mv, now)
+		if err := s.repositories.SetHealth(ctx, repo.URL, repo.Health); err != nil {
+			return repo, err
 		}
-		if godocInfo.Importers > 0 {
-			repo.Statistics = append(repo.Statistics, Statistic{
-				Name:  "Importers",
-				Value: godocInfo.Importers,
-				URL:   fmt.Sprintf("https://godoc.org/%s?importers", repo.URL),
-			})
+	}
+
+	return repo, nil
+}
+
+// Indexed returns if a repository is known already, otherwise Get fetches it
+func (s *service) Indexed(ctx context.Context, url string) (bool, error) {
+	return s.repositories.Exists(ctx, url)
+}
+
+// Sources of a repository's data, that may be missing if they fail
+const (
+	sourceGoDoc = "godoc.org"
+	sourceProxy = "the module proxy"
+)
+
+// fetchMissing fetches a repository that isn't indexed yet.
+// Repositories that weren't found are remembered for a while, in memory and the storage,
+// so that requests for them don't hit GitHub and godoc.org every time.
+// Only repositories that don't exist are remembered, not ones that failed to be fetched.
+func (s *service) fetchMissing(ctx context.Context, url string) (Repository, error) {
+	if s.missing.missing(url, time.Now()) {
+		return Repository{}, ErrNotFound
+	}
+	checked, err := s.repositories.GetMissing(ctx, url)
+	if err != nil {
+		return Repository{}, err
+	}
+	if time.Since(checked) < missingTTL {
+		s.missing.add(url, checked)
+		return Repository{}, ErrNotFound
+	}
+
+	repo, err := s.fetch(ctx, url)
+	if err == ErrNotFound {
+		now := time.Now()
+		s.missing.add(url, now)
+		if err := s.repositories.SetMissing(ctx, url, now); err != nil {
+			return repo, err
 		}
+		return repo, ErrNotFound
+	}
 
-		if err := s.repositories.Create(ctx, repo); err != nil {
+	return repo, err
+}
+
+// fetch requests a repository's data from GitHub, godoc.org and the module proxy.
+// GitHub's data is required, the repository is returned without the data of
+// godoc.org or the module proxy if they fail and marked as incomplete.
+func (s *service) fetch(ctx context.Context, url string) (Repository, error) {
+	var incomplete []string
+
+	godocInfo, err := s.godoc.Get(ctx, url)
+	if err == ErrNotFound {
+		return Repository{}, err
+	}
+	if err != nil {
+		incomplete = append(incomplete, sourceGoDoc)
+		godocInfo = &GoDocInfo{}
+	}
+
+	repo, err := s.github.Get(ctx, url)
+	if err != nil {
+		return repo, err
+	}
+	repo.Incomplete = incomplete
+
+	sortVersions(repo.Versions)
+
+	modules, retractions, err := s.modules(ctx, repo)
+	if err != nil {
+		if _, ok := upstream(err); !ok {
 			return repo, err
 		}
+		repo.Incomplete = append(repo.Incomplete, sourceProxy)
 	}
+	repo.Modules = modules
+	retractVersions(repo.Versions, retractions)
 
-	repo, err := s.repositories.Get(ctx, url)
+	if godocInfo.Imports > 0 {
+		repo.Statistics = append(repo.Statistics, Statistic{
+			Name:  "Imports",
+			Value: godocInfo.Imports,
+			URL:   fmt.Sprintf("https://godoc.org/%s?imports", repo.URL),
+		})
+	}
+	if godocInfo.Importers > 0 {
+		repo.Statistics = append(repo.Statistics, Statistic{
+			Name:  "Importers",
+			Value: godocInfo.Importers,
+			URL:   fmt.Sprintf("https://godoc.org/%s?importers", repo.URL),
+		})
+	}
+
+	return repo, nil
+}
+
+// status returns the status of a repository,
+// the go.mod of its current version is checked for deprecations and retractions.
+// A go.mod that can't be fetched doesn't keep the repository from being shown,
+// its status is then only based on GitHub's data.
+func (s *service) status(ctx context.Context, repo Repository) Status {
+	var mod GoMod
+	if path, version, ok := canonicalVersion(repo, repo.CurrentVersion.Name); ok {
+		if m, err := s.goMod(ctx, path, version); err == nil {
+			mod = m
+		}
+	}
+
+	return newStatus(repo, mod, s.inactivity, time.Now())
+}
+
+// repositoryVulnerabilities returns the vulnerabilities of a repository's current version
+// and of the modules of its build list, if the version was analyzed before.
+func (s *service) repositoryVulnerabilities(ctx context.Context, repo Repository) ([]Vulnerability, error) {
+	path, version, ok := canonicalVersion(repo, repo.CurrentVersion.Name)
+	if !ok {
+		return nil, nil
+	}
+
+	mv, err := s.repositories.GetModuleVersion(ctx, path, version)
 	if err != nil && err != ErrNotFound {
-		return repo, err
+		return nil, err
 	}
 
-	return repo, err
+	return s.vulnerabilities(ctx, mv)
+}
+
+// vulnerabilities returns the vulnerabilities of a module version and its build list
+func (s *service) vulnerabilities(ctx context.Context, mv ModuleVersion) ([]Vulnerability, error) {
+	modules := append([]Dependency{{Path: mv.Path, Version: mv.Version}}, mv.BuildList...)
+
+	paths := make([]string, len(modules))
+	for i, m := range modules {
+		paths[i] = m.Path
+	}
+
+	advisories, err := s.repositories.GetAdvisories(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return matchAdvisories(advisories, modules), nil
+}
+
+func (s *service) ImportAdvisories(ctx context.Context, advisories []Advisory) error {
+	return s.repositories.CreateAdvisories(ctx, advisories)
+}
+
+// modules looks up the module path of every major version
+// by requesting the go.mod of each major version's latest tag.
+// The versions retracted by these go.mod files are returned by major version too.
+func (s *service) modules(ctx context.Context, repo Repository) ([]Module, map[int][]Retraction, error) {
+	var modules []Module
+	retractions := map[int][]Retraction{}
+	for major, v := range latestMajorVersions(repo.Versions) {
+		m := Module{Major: major, Version: v.Name}
+
+		data, err := s.proxy.Mod(ctx, expectedModulePath(repo.URL, major), v.Name)
+		if err != nil && err != ErrNotFound {
+			return nil, nil, errors.Wrapf(err, "failed to get go.mod of %s", v.Name)
+		}
+		if err == nil {
+			mod, err := parseGoMod(data)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to parse go.mod of %s", v.Name)
+			}
+			m.Path = mod.Module
+			retractions[major] = mod.Retract
+		}
+
+		modules = append(modules, m)
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Major < modules[j].Major
+	})
+
+	return modules, retractions, nil
+}
+
+// versionsPerPage is the number of versions listed on a single page
+const versionsPerPage = 50
+
+// VersionList is a single page of all versions of a Repository
+type VersionList struct {
+	Versions []Version
+	Page     int
+	Pages    int
+}
+
+func (s *service) Versions(ctx context.Context, url string, page int) (VersionList, error) {
+	if page < 1 {
+		page = 1
+	}
+
+	versions, count, err := s.repositories.GetVersions(ctx, url, versionsPerPage, (page-1)*versionsPerPage)
+	if err != nil {
+		return VersionList{}, err
+	}
+
+	return VersionList{
+		Versions: versions,
+		Page:     page,
+		Pages:    (count + versionsPerPage - 1) / versionsPerPage,
+	}, nil
+}
+
+func (s *service) ModuleVersion(ctx context.Context, url string, version string) (ModuleVersion, error) {
+	repo, err := s.repositories.Get(ctx, url)
+	if err != nil {
+		return ModuleVersion{}, err
+	}
+
+	if _, err := s.repositories.GetVersion(ctx, url, version); err != nil {
+		return ModuleVersion{}, err
+	}
+
+	path, moduleVersion, err := s.resolveVersion(ctx, repo, version)
+	if err != nil {
+		return ModuleVersion{}, err
+	}
+
+	mv, err := s.moduleVersion(ctx, path, moduleVersion)
+	if err != nil {
+		return mv, err
+	}
+
+	mv.Vulnerabilities, err = s.vulnerabilities(ctx, mv)
+
+	return mv, err
+}
+
+// StoredModuleVersion returns a module version only if it was analyzed before.
+// Unlike ModuleVersion it doesn't fetch anything, so pages can show it right away.
+func (s *service) StoredModuleVersion(ctx context.Context, url string, version string) (ModuleVersion, error) {
+	repo, err := s.repositories.Get(ctx, url)
+	if err != nil {
+		return ModuleVersion{}, err
+	}
+
+	path, moduleVersion, ok := canonicalVersion(repo, version)
+	if !ok {
+		return ModuleVersion{}, ErrNotFound
+	}
+	mv, err := s.repositories.GetModuleVersion(ctx, path, moduleVersion)
+	if err != nil {
+		return mv, err
+	}
+	if !mv.BuildListResolved || !mv.LicensesDetected {
+		return mv, ErrNotFound
+	}
+
+	mv.Vulnerabilities, err = s.vulnerabilities(ctx, mv)
+
+	return mv, err
+}
+
+// maxAnalysisAttempts is the number of times a version is analyzed before it's given up
+const maxAnalysisAttempts = 8
+
+// Analyze analyzes the current version of a repository in the background.
+// Versions that fail to be analyzed are retried with backoff and given up eventually.
+func (s *service) Analyze(ctx context.Context, url string) error {
+	repo, err := s.repositories.Get(ctx, url)
+	if err != nil {
+		return err
+	}
+	version := repo.CurrentVersion.Name
+	if version == "" {
+		return nil
+	}
+
+	attempt, err := s.repositories.GetAnalysisAttempt(ctx, url, version)
+	if err != nil {
+		return err
+	}
+	if !attempt.due(time.Now(), maxAnalysisAttempts) {
+		return nil
+	}
+
+	_, err = s.ModuleVersion(ctx, url, version)
+	// Analyses canceled on shutdown aren't attempts that failed
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	attempt = attempt.failed(err, time.Now())
+	// Versions that don't exist as modules will never be analyzed
+	if errors.Cause(err) == ErrNotFound {
+		attempt.Count = maxAnalysisAttempts
+	}
+	if err := s.repositories.SetAnalysisAttempt(ctx, url, version, attempt); err != nil {
+		return err
+	}
+
+	return err
+}
+
+// resolveVersion returns the module path and module version of a repository's tag.
+// Tags that aren't canonical semantic versions are resolved by the module proxy.
+func (s *service) resolveVersion(ctx context.Context, repo Repository, version string) (string, string, error) {
+	if path, moduleVersion, ok := canonicalVersion(repo, version); ok {
+		return path, moduleVersion, nil
+	}
+
+	path := repo.URL
+	if sv, ok := parseSemver(version); ok {
+		path = repo.ImportPath(sv.major)
+	}
+	resolved, err := s.proxy.Info(ctx, path, version)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to resolve %s", version)
+	}
+
+	return path, resolved, nil
+}
+
+// canonicalVersion returns the module path and module version of a repository's tag
+// that is a canonical semantic version. Major versions without a go.mod are +incompatible.
+func canonicalVersion(repo Repository, version string) (string, string, bool) {
+	sv, ok := parseSemver(version)
+	if !ok || sv.String() != version {
+		return "", "", false
+	}
+
+	path := repo.ImportPath(sv.major)
+	if sv.major >= 2 && path == repo.URL {
+		version += "+incompatible"
+	}
+
+	return path, version, true
+}
+
+// moduleVersion returns a stored module version or
+// fetches, analyzes and stores it if it wasn't requested before.
+func (s *service) moduleVersion(ctx context.Context, path, version string) (ModuleVersion, error) {
+	mv, err := s.repositories.GetModuleVersion(ctx, path, version)
+	if err == ErrNotFound {
+		mv, err = s.createModuleVersion(ctx, mv)
+	}
+	if err != nil {
+		return mv, err
+	}
+
+	if !mv.BuildListResolved {
+		mod, err := parseGoMod([]byte(mv.GoMod))
+		if err != nil {
+			return mv, errors.Wrapf(err, "failed to parse go.mod of %s@%s", path, version)
+		}
+		mv.BuildList, err = buildList(mod, s.requirements(ctx))
+		if err != nil {
+			return mv, errors.Wrapf(err, "failed to resolve build list of %s@%s", path, version)
+		}
+		if err := s.repositories.SetBuildList(ctx, path, version, mv.BuildList); err != nil {
+			return mv, err
+		}
+		mv.BuildListResolved = true
+	}
+
+	// Module versions stored before licenses were detected are fetched again
+	if !mv.LicensesDetected {
+		zr, err := s.proxy.Zip(ctx, path, version)
+		if err != nil && err != ErrZipTooLarge {
+			return mv, errors.Wrapf(err, "failed to get zip of %s@%s", path, version)
+		}
+		if err == nil {
+			mv.Licenses, err = detectLicenses(moduleFiles(zr, path, version))
+			if err != nil {
+				return mv, errors.Wrapf(err, "failed to detect licenses of %s@%s", path, version)
+			}
+		}
+		if err := s.repositories.SetModuleLicenses(ctx, path, version, mv.Licenses); err != nil {
+			return mv, err
+		}
+		mv.LicensesDetected = true
+	}
+
+	return mv, nil
+}
+
+// requirements returns a function returning the requirements of a module version's go.mod
+func (s *service) requirements(ctx context.Context) func(path, version string) ([]Dependency, error) {
+	return func(path, version string) ([]Dependency, error) {
+		mod, err := s.goMod(ctx, path, version)
+		if err == ErrNotFound {
+			// Modules that can't be found are treated like they had no requirements
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return mod.Require, nil
+	}
+}
+
+// goMod returns the parsed go.mod of a module version.
+// The go.mod files are stored, as they are requested again for other build lists.
+func (s *service) goMod(ctx context.Context, path, version string) (GoMod, error) {
+	gomod, err := s.repositories.GetGoMod(ctx, path, version)
+	if err == ErrNotFound {
+		data, err := s.proxy.Mod(ctx, path, version)
+		if err == ErrNotFound {
+			return GoMod{}, err
+		}
+		if err != nil {
+			return GoMod{}, errors.Wrapf(err, "failed to get go.mod of %s@%s", path, version)
+		}
+		gomod = string(data)
+
+		if err := s.repositories.CreateGoMod(ctx, path, version, gomod); err != nil {
+			return GoMod{}, err
+		}
+	} else if err != nil {
+		return GoMod{}, err
+	}
+
+	mod, err := parseGoMod([]byte(gomod))
+	if err != nil {
+		return mod, errors.Wrapf(err, "failed to parse go.mod of %s@%s", path, version)
+	}
+	return mod, nil
+}
+
+// createModuleVersion fetches, analyzes and stores a module version
+func (s *service) createModuleVersion(ctx context.Context, mv ModuleVersion) (ModuleVersion, error) {
+	path, version := mv.Path, mv.Version
+
+	data, err := s.proxy.Mod(ctx, path, version)
+	if err != nil {
+		return mv, errors.Wrapf(err, "failed to get go.mod of %s@%s", path, version)
+	}
+	mod, err := parseGoMod(data)
+	if err != nil {
+		return mv, errors.Wrapf(err, "failed to parse go.mod of %s@%s", path, version)
+	}
+	mv.GoMod = string(data)
+	mv.Dependencies = mod.Require
+
+	zr, err := s.proxy.Zip(ctx, path, version)
+	if err != nil && err != ErrZipTooLarge {
+		return mv, errors.Wrapf(err, "failed to get zip of %s@%s", path, version)
+	}
+	// Modules too large to download are stored without documentation and licenses
+	mv.LicensesDetected = true
+	if err == nil {
+		files := moduleFiles(zr, path, version)
+		mv.LicenseFiles = licenseFiles(files)
+		mv.Tests = hasTests(files)
+		mv.Licenses, err = detectLicenses(files)
+		if err != nil {
+			return mv, errors.Wrapf(err, "failed to detect licenses of %s@%s", path, version)
+		}
+		mv.Packages, err = parsePackages(files, path)
+		if err != nil {
+			return mv, errors.Wrapf(err, "failed to parse packages of %s@%s", path, version)
+		}
+	}
+
+	if err := s.repositories.CreateModuleVersion(ctx, mv); err != nil {
+		return mv, err
+	}
+
+	return mv, nil
+}
+
+func (s *service) Compare(ctx context.Context, url string, from, to string) (Comparison, error) {
+	fromVersion, err := s.ModuleVersion(ctx, url, from)
+	if err != nil {
+		return Comparison{}, err
+	}
+	toVersion, err := s.ModuleVersion(ctx, url, to)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	releases, err := s.repositories.GetVersionRange(ctx, url, from, to)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	return Comparison{
+		From:         fromVersion,
+		To:           toVersion,
+		Dependencies: diffDependencies(fromVersion.Dependencies, toVersion.Dependencies),
+		API:          diffPackages(fromVersion, toVersion),
+		Releases:     releases,
+	}, nil
+}
+
+func (s *service) Compatibility(ctx context.Context, url string, from, to string) (Compatibility, error) {
+	repo, err := s.repositories.Get(ctx, url)
+	if err != nil {
+		return Compatibility{}, err
+	}
+
+	for _, v := range []string{from, to} {
+		if _, err := s.repositories.GetVersion(ctx, url, v); err != nil {
+			return Compatibility{}, err
+		}
+	}
+
+	return s.compatibility(ctx, repo, from, to)
 }
 
-// Homepage contains urls of repositories with different categories
-type Homepage struct {
-	Popular []string
-	Latest  []string
-	Random  []string
+// StoredCompatibility returns the API compatibility of two versions only if it was checked before
+func (s *service) StoredCompatibility(ctx context.Context, url string, from, to string) (Compatibility, error) {
+	return s.repositories.GetCompatibility(ctx, url, from, to)
 }
 
-func (s *service) Homepage(ctx context.Context) (Homepage, error) {
-	limit := 15
+// compatibility checks the API compatibility of two versions, unless it was checked before.
+// Versions don't change, so the result is stored and shared by the API and CheckVersions.
+func (s *service) compatibility(ctx context.Context, repo Repository, from, to string) (Compatibility, error) {
+	if c, err := s.repositories.GetCompatibility(ctx, repo.URL, from, to); err != ErrNotFound {
+		return c, err
+	}
+
+	fromPath, fromPackages, err := s.typeCheck(ctx, repo, from)
+	if err != nil {
+		return Compatibility{}, err
+	}
+	toPath, toPackages, err := s.typeCheck(ctx, repo, to)
+	if err != nil {
+		return Compatibility{}, err
+	}
 
-	// TODO: Use a sync.WaitGroup to run this concurrently
+	c := Compatibility{
+		From:    from,
+		To:      to,
+		Changes: compareAPI(fromPackages, toPackages, fromPath, toPath),
+	}
+	for _, change := range c.Changes {
+		if change.Breaking {
+			c.Breaking = true
+			break
+		}
+	}
 
-	h := Homepage{}
+	return c, s.repositories.CreateCompatibility(ctx, repo.URL, c)
+}
 
-	popular, err := s.repositories.GetPopular(ctx, limit)
+// typeCheck downloads a version of a repository's module and type checks its packages
+func (s *service) typeCheck(ctx context.Context, repo Repository, version string) (string, map[string]*types.Package, error) {
+	path, moduleVersion, err := s.resolveVersion(ctx, repo, version)
 	if err != nil {
-		return h, err
+		return "", nil, err
 	}
 
-	latest, err := s.repositories.GetLatest(ctx, limit)
+	zr, err := s.proxy.Zip(ctx, path, moduleVersion)
 	if err != nil {
-		return h, err
+		return path, nil, errors.Wrapf(err, "failed to get zip of %s@%s", path, moduleVersion)
 	}
 
-	random, err := s.repositories.GetRandom(ctx, limit)
+	packages, err := typeCheck(moduleFiles(zr, path, moduleVersion), path)
 	if err != nil {
-		return h, err
+		return path, nil, errors.Wrapf(err, "failed to type check %s@%s", path, moduleVersion)
 	}
 
-	return Homepage{
-		Popular: popular,
-		Latest:  latest,
-		Random:  random,
+	return path, packages, nil
+}
+
+// VersionCheck is a version whose API wasn't yet compared to its previous version
+type VersionCheck struct {
+	URL      string
+	Version  string
+	Previous string
+	Attempt  Attempt
+}
+
+// versionChecks is the number of versions checked by a single run of CheckVersions
+const versionChecks = 25
+
+// maxCheckAttempts is the number of times a version is checked before it's given up
+const maxCheckAttempts = 8
+
+// CheckVersions compares the API of versions that weren't checked yet to their
+// previous version and flags them as breaking if they break the API without
+// a new major version. Versions of major version 0 are never breaking.
+// Versions that fail to be checked are retried with backoff and given up eventually,
+// so that they don't keep other versions from being checked.
+func (s *service) CheckVersions(ctx context.Context) error {
+	checks, err := s.repositories.GetUncheckedVersions(ctx, time.Now(), versionChecks)
+	if err != nil {
+		return err
+	}
+
+	repos := map[string]Repository{}
+	var checkErr error
+	for _, c := range checks {
+		breaking, err := s.checkVersion(ctx, repos, c)
+		// Checks canceled on shutdown aren't attempts that failed
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			attempt := c.Attempt.failed(err, time.Now())
+			// Versions that can't be downloaded will never be checked
+			cause := errors.Cause(err)
+			if cause == ErrNotFound || cause == ErrZipTooLarge {
+				attempt.Count = maxCheckAttempts
+			} else if checkErr == nil {
+				checkErr = errors.Wrapf(err, "failed to check %s@%s", c.URL, c.Version)
+			}
+			if err := s.repositories.SetCheckAttempt(ctx, c.URL, c.Version, attempt); err != nil {
+				return err
+			}
+			if attempt.Count < maxCheckAttempts {
+				continue
+			}
+			// Versions that are given up are flagged as checked and not breaking
+			breaking = false
+		}
+
+		if err := s.repositories.SetVersionBreaking(ctx, c.URL, c.Version, breaking); err != nil {
+			return err
+		}
+	}
+
+	return checkErr
+}
+
+func (s *service) checkVersion(ctx context.Context, repos map[string]Repository, c VersionCheck) (bool, error) {
+	if c.Previous == "" {
+		return false, nil
+	}
+	prev, ok := parseSemver(c.Previous)
+	if !ok {
+		return false, nil
+	}
+	cur, ok := parseSemver(c.Version)
+	if !ok || cur.major == 0 || cur.major != prev.major {
+		return false, nil
+	}
+
+	repo, ok := repos[c.URL]
+	if !ok {
+		var err error
+		repo, err = s.repositories.Get(ctx, c.URL)
+		if err != nil {
+			return false, err
+		}
+		repos[c.URL] = repo
+	}
+
+	compat, err := s.compatibility(ctx, repo, c.Previous, c.Version)
+	if err != nil {
+		return false, err
+	}
+
+	return compat.Breaking, nil
+}
+
+// Graph returns the transitive dependency graph of a version up to a depth,
+// walking the requirements of the dependencies' go.mod files.
+// The current version's graph is returned if no version is given.
+func (s *service) Graph(ctx context.Context, url string, version string, depth int) (Graph, error) {
+	if depth < 1 || depth > maxGraphDepth {
+		depth = defaultGraphDepth
+	}
+
+	if version == "" {
+		repo, err := s.repositories.Get(ctx, url)
+		if err != nil {
+			return Graph{}, err
+		}
+		if repo.CurrentVersion.Name == "" {
+			return Graph{}, ErrNotFound
+		}
+		version = repo.CurrentVersion.Name
+	}
+
+	mv, err := s.ModuleVersion(ctx, url, version)
+	if err != nil {
+		return Graph{}, err
+	}
+
+	mod, err := parseGoMod([]byte(mv.GoMod))
+	if err != nil {
+		return Graph{}, errors.Wrapf(err, "failed to parse go.mod of %s@%s", mv.Path, mv.Version)
+	}
+	// The go.mod files of all module versions reachable through the requirements
+	// were stored while resolving the build list
+	edges, err := dependencyEdges(mod, mv.Version, depth, s.requirements(ctx))
+	if err != nil {
+		return Graph{}, err
+	}
+
+	return newGraph(mv.Path, mv.Version, depth, edges), nil
+}
+
+// Licenses returns the license report of a version and its build list.
+// The licenses of modules are the ones of their repositories known to godep,
+// modules of other repositories are reported with an unknown license.
+func (s *service) Licenses(ctx context.Context, url string, version string) (LicenseReport, error) {
+	if version == "" {
+		repo, err := s.repositories.Get(ctx, url)
+		if err != nil {
+			return LicenseReport{}, err
+		}
+		if repo.CurrentVersion.Name == "" {
+			return LicenseReport{}, ErrNotFound
+		}
+		version = repo.CurrentVersion.Name
+	}
+
+	mv, err := s.ModuleVersion(ctx, url, version)
+	if err != nil {
+		return LicenseReport{}, err
+	}
+
+	modules := append([]Dependency{{Path: mv.Path, Version: mv.Version}}, mv.BuildList...)
+
+	var urls []string
+	for _, m := range modules {
+		if u := moduleRepository(m.Path); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	repoLicenses, err := s.repositories.GetLicenses(ctx, urls)
+	if err != nil {
+		return LicenseReport{}, err
+	}
+
+	detected, err := s.repositories.GetModuleLicenses(ctx, mv.BuildList)
+	if err != nil {
+		return LicenseReport{}, err
+	}
+	detected[graphID(mv.Path, mv.Version)] = mv.Licenses
+
+	// Licenses detected in a module's files are preferred over the license GitHub detected for its repository
+	licenses := map[string]string{}
+	for _, m := range modules {
+		if expr := licenseExpression(detected[graphID(m.Path, m.Version)]); expr != "" {
+			licenses[m.Path] = expr
+			continue
+		}
+		if l, ok := repoLicenses[moduleRepository(m.Path)]; ok && l.SPDX != "NOASSERTION" {
+			licenses[m.Path] = l.SPDX
+		}
+	}
+
+	return newLicenseReport(mv.Path, mv.Version, modules, licenses, s.licensePolicy), nil
+}
+
+// ReleaseFeed returns a feed of a repository's latest releases
+func (s *service) ReleaseFeed(ctx context.Context, url string) (Feed, error) {
+	repo, err := s.Get(ctx, url)
+	if err != nil {
+		return Feed{}, err
+	}
+
+	releases, err := s.repositories.GetReleases(ctx, repo.URL, feedEntries)
+	if err != nil {
+		return Feed{}, err
+	}
+
+	return Feed{
+		Title:       repo.URL + " releases",
+		Link:        "/" + repo.URL,
+		Description: repo.Description,
+		Entries:     releaseFeedEntries(releases),
 	}, nil
 }
+
+// IndexFeed returns a feed of the repositories indexed and the releases published most recently
+func (s *service) IndexFeed(ctx context.Context) (Feed, error) {
+	releases, err := s.repositories.GetReleases(ctx, "", feedEntries)
+	if err != nil {
+		return Feed{}, err
+	}
+	repos, err := s.repositories.GetNewest(ctx, feedEntries)
+	if err != nil {
+		return Feed{}, err
+	}
+
+	entries := releaseFeedEntries(releases)
+	for _, r := range repos {
+		entries = append(entries, FeedEntry{
+			Title:     r.URL + " indexed",
+			Link:      "/" + r.URL,
+			Content:   r.Description,
+			Published: r.Created,
+		})
+	}
+	sortFeedEntries(entries)
+	if len(entries) > feedEntries {
+		entries = entries[:feedEntries]
+	}
+
+	return Feed{
+		Title:       "godep.org",
+		Link:        "/",
+		Description: "New packages and releases on godep.org",
+		Entries:     entries,
+	}, nil
+}
+
+func (s *service) Subscribe(ctx context.Context, sub Subscription) (Subscription, error) {
+	sub, err := sub.normalize()
+	if err != nil {
+		return sub, err
+	}
+
+	if err := checkPublicHost(ctx, sub.host()); err != nil {
+		return sub, errors.Wrap(ErrInvalidSubscription, "url needs to be a public address")
+	}
+
+	// Watched repositories are refreshed in the background, which would fetch repositories
+	// that aren't indexed yet without the fetch rate limit. They need to be requested first.
+	for _, url := range sub.Repositories {
+		exists, err := s.repositories.Exists(ctx, url)
+		if err != nil {
+			return sub, err
+		}
+		if !exists {
+			return sub, errors.Wrapf(ErrInvalidSubscription, "%s isn't indexed yet, request its page first", url)
+		}
+	}
+
+	return s.repositories.CreateSubscription(ctx, sub)
+}
+
+func (s *service) Subscription(ctx context.Context, id string) (Subscription, error) {
+	return s.repositories.GetSubscription(ctx, id)
+}
+
+func (s *service) Unsubscribe(ctx context.Context, id string) error {
+	return s.repositories.DeleteSubscription(ctx, id)
+}
+
+// Refresh fetches a repository again and updates it. Subscriptions watching the
+// repository are notified about new versions, it being archived and advisories
+// affecting its current version. Only repositories that are indexed already are refreshed,
+// unknown ones are fetched by Get, which is rate limited much stricter.
+func (s *service) Refresh(ctx context.Context, url string) error {
+	old, err := s.repositories.Get(ctx, url)
+	if err != nil {
+		return err
+	}
+	versions, _, err := s.repositories.GetVersions(ctx, url, old.VersionsCount, 0)
+	if err != nil {
+		return err
+	}
+	known := make([]string, len(versions))
+	for i, v := range versions {
+		known[i] = v.Name
+	}
+
+	repo, err := s.fetch(ctx, url)
+	if err != nil {
+		return err
+	}
+	repo = keepIncomplete(old, repo)
+	if err := s.repositories.Update(ctx, repo); err != nil {
+		return err
+	}
+
+	events := repositoryEvents(old, known, repo, time.Now())
+
+	// Vulnerabilities are only known for analyzed versions, so the current version is analyzed first
+	if current := currentVersion(repo.Versions); current.Name != "" {
+		_, err := s.ModuleVersion(ctx, url, current.Name)
+		if cause := errors.Cause(err); err != nil && cause != ErrNotFound && cause != ErrZipTooLarge {
+			return err
+		}
+	}
+	repo, err = s.Get(ctx, url)
+	if err != nil {
+		return err
+	}
+	events = append(events, advisoryEvents(repo)...)
+
+	return s.repositories.CreateDeliveries(ctx, events)
+}
+
+// keepIncomplete keeps the data of an old state of a repository,
+// which is missing in the new state as its source failed.
+func keepIncomplete(old, repo Repository) Repository {
+	for _, source := range repo.Incomplete {
+		switch source {
+		case sourceGoDoc:
+			for _, stat := range old.Statistics {
+				if stat.Name == "Imports" || stat.Name == "Importers" {
+					repo.Statistics = append(repo.Statistics, stat)
+				}
+			}
+		case sourceProxy:
+			repo.Modules = old.Modules
+		}
+	}
+	return repo
+}
+
+// CheckSubscriptions refreshes the repositories watched by subscriptions,
+// which weren't updated or checked within the last hour.
+func (s *service) CheckSubscriptions(ctx context.Context) error {
+	urls, err := s.repositories.GetWatched(ctx, time.Now().Add(-watchedRefresh), watchedChecks)
+	if err != nil {
+		return err
+	}
+
+	var refreshErr error
+	for _, url := range urls {
+		if err := s.repositories.SetWatchChecked(ctx, url, time.Now()); err != nil {
+			return err
+		}
+		if err := s.Refresh(ctx, url); err != nil && refreshErr == nil {
+			refreshErr = errors.Wrapf(err, "failed to refresh %s", url)
+		}
+	}
+
+	return refreshErr
+}
+
+// Notify sends the pending deliveries to the webhooks of their subscriptions.
+// Webhooks that fail are recorded in the delivery log and retried later.
+func (s *service) Notify(ctx context.Context) error {
+	deliveries, err := s.repositories.GetPendingDeliveries(ctx, pendingDeliveries)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deliveries {
+		status, err := s.webhooks.Send(ctx, d)
+		if err := s.repositories.UpdateDelivery(ctx, d.attempted(status, err, time.Now())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -20,7 +20,27 @@ func NewMetricService(s Service, calls metrics.Histogram) Service {
 	}
 
 	ms.calls.With("method", "get").Observe(0)
+	ms.calls.With("method", "indexed").Observe(0)
 	ms.calls.With("method", "homepage").Observe(0)
+	ms.calls.With("method", "versions").Observe(0)
+	ms.calls.With("method", "module_version").Observe(0)
+	ms.calls.With("method", "stored_module_version").Observe(0)
+	ms.calls.With("method", "analyze").Observe(0)
+	ms.calls.With("method", "compare").Observe(0)
+	ms.calls.With("method", "compatibility").Observe(0)
+	ms.calls.With("method", "stored_compatibility").Observe(0)
+	ms.calls.With("method", "check_versions").Observe(0)
+	ms.calls.With("method", "graph").Observe(0)
+	ms.calls.With("method", "import_advisories").Observe(0)
+	ms.calls.With("method", "licenses").Observe(0)
+	ms.calls.With("method", "release_feed").Observe(0)
+	ms.calls.With("method", "index_feed").Observe(0)
+	ms.calls.With("method", "subscribe").Observe(0)
+	ms.calls.With("method", "subscription").Observe(0)
+	ms.calls.With("method", "unsubscribe").Observe(0)
+	ms.calls.With("method", "refresh").Observe(0)
+	ms.calls.With("method", "check_subscriptions").Observe(0)
+	ms.calls.With("method", "notify").Observe(0)
 
 	return ms
 }
@@ -33,6 +53,14 @@ func (ms *metricService) Get(ctx context.Context, url string) (Repository, error
 	return ms.service.Get(ctx, url)
 }
 
+func (ms *metricService) Indexed(ctx context.Context, url string) (bool, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "indexed").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Indexed(ctx, url)
+}
+
 func (ms *metricService) Homepage(ctx context.Context) (Homepage, error) {
 	defer func(start time.Time) {
 		ms.calls.With("method", "homepage").Observe(time.Since(start).Seconds())
@@ -40,3 +68,155 @@ func (ms *metricService) Homepage(ctx context.Context) (Homepage, error) {
 
 	return ms.service.Homepage(ctx)
 }
+
+func (ms *metricService) Versions(ctx context.Context, url string, page int) (VersionList, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "versions").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Versions(ctx, url, page)
+}
+
+func (ms *metricService) ModuleVersion(ctx context.Context, url string, version string) (ModuleVersion, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "module_version").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.ModuleVersion(ctx, url, version)
+}
+
+func (ms *metricService) StoredModuleVersion(ctx context.Context, url string, version string) (ModuleVersion, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "stored_module_version").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.StoredModuleVersion(ctx, url, version)
+}
+
+func (ms *metricService) Analyze(ctx context.Context, url string) error {
+	defer func(start time.Time) {
+		ms.calls.With("method", "analyze").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Analyze(ctx, url)
+}
+
+func (ms *metricService) Compare(ctx context.Context, url string, from, to string) (Comparison, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "compare").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Compare(ctx, url, from, to)
+}
+
+func (ms *metricService) Compatibility(ctx context.Context, url string, from, to string) (Compatibility, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "compatibility").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Compatibility(ctx, url, from, to)
+}
+
+func (ms *metricService) StoredCompatibility(ctx context.Context, url string, from, to string) (Compatibility, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "stored_compatibility").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.StoredCompatibility(ctx, url, from, to)
+}
+
+func (ms *metricService) CheckVersions(ctx context.Context) error {
+	defer func(start time.Time) {
+		ms.calls.With("method", "check_versions").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.CheckVersions(ctx)
+}
+
+func (ms *metricService) Graph(ctx context.Context, url string, version string, depth int) (Graph, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "graph").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Graph(ctx, url, version, depth)
+}
+
+func (ms *metricService) ImportAdvisories(ctx context.Context, advisories []Advisory) error {
+	defer func(start time.Time) {
+		ms.calls.With("method", "import_advisories").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.ImportAdvisories(ctx, advisories)
+}
+
+func (ms *metricService) Licenses(ctx context.Context, url string, version string) (LicenseReport, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "licenses").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Licenses(ctx, url, version)
+}
+
+func (ms *metricService) ReleaseFeed(ctx context.Context, url string) (Feed, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "release_feed").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.ReleaseFeed(ctx, url)
+}
+
+func (ms *metricService) IndexFeed(ctx context.Context) (Feed, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "index_feed").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.IndexFeed(ctx)
+}
+
+func (ms *metricService) Subscribe(ctx context.Context, sub Subscription) (Subscription, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "subscribe").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Subscribe(ctx, sub)
+}
+
+func (ms *metricService) Subscription(ctx context.Context, id string) (Subscription, error) {
+	defer func(start time.Time) {
+		ms.calls.With("method", "subscription").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Subscription(ctx, id)
+}
+
+func (ms *metricService) Unsubscribe(ctx context.Context, id string) error {
+	defer func(start time.Time) {
+		ms.calls.With("method", "unsubscribe").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Unsubscribe(ctx, id)
+}
+
+func (ms *metricService) Refresh(ctx context.Context, url string) error {
+	defer func(start time.Time) {
+		ms.calls.With("method", "refresh").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Refresh(ctx, url)
+}
+
+func (ms *metricService) CheckSubscriptions(ctx context.Context) error {
+	defer func(start time.Time) {
+		ms.calls.With("method", "check_subscriptions").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.CheckSubscriptions(ctx)
+}
+
+func (ms *metricService) Notify(ctx context.Context) error {
+	defer func(start time.Time) {
+		ms.calls.With("method", "notify").Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	return ms.service.Notify(ctx)
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type checkStorage struct {
+	Storage
+	checks   []VersionCheck
+	attempts map[string]Attempt
+	checked  map[string]bool
+}
+
+func (s *checkStorage) GetUncheckedVersions(ctx context.Context, before time.Time, limit int) ([]VersionCheck, error) {
+	return s.checks, nil
+}
+
+func (s *checkStorage) Get(ctx context.Context, url string) (Repository, error) {
+	if url == "github.com/foo/missing" {
+		return Repository{}, ErrNotFound
+	}
+	return Repository{}, errors.New("failed to query")
+}
+
+func (s *checkStorage) SetCheckAttempt(ctx context.Context, url string, name string, a Attempt) error {
+	s.attempts[url+"@"+name] = a
+	return nil
+}
+
+func (s *checkStorage) SetVersionBreaking(ctx context.Context, url string, name string, breaking bool) error {
+	s.checked[url+"@"+name] = true
+	return nil
+}
+
+func TestCheckVersionsAttempts(t *testing.T) {
+	repositories := &checkStorage{
+		checks: []VersionCheck{
+			{URL: "github.com/foo/bar", Version: "v1.1.0", Previous: "v1.0.0"},
+			{URL: "github.com/foo/bar", Version: "v1.2.0", Previous: "v1.1.0", Attempt: Attempt{Count: maxCheckAttempts - 1}},
+			{URL: "github.com/foo/missing", Version: "v1.1.0", Previous: "v1.0.0"},
+			{URL: "github.com/foo/bar", Version: "v2.0.0", Previous: "v1.2.0"},
+		},
+		attempts: map[string]Attempt{},
+		checked:  map[string]bool{},
+	}
+	s := &service{repositories: repositories}
+
+	if err := s.CheckVersions(context.Background()); err == nil {
+		t.Error("expected the failed check to be returned")
+	}
+
+	tests := []struct {
+		version  string
+		attempts int
+		checked  bool
+	}{
+		// Failed versions are retried later
+		{version: "github.com/foo/bar@v1.1.0", attempts: 1, checked: false},
+		// and given up after too many attempts
+		{version: "github.com/foo/bar@v1.2.0", attempts: maxCheckAttempts, checked: true},
+		// Versions that can't be found are given up right away
+		{version: "github.com/foo/missing@v1.1.0", attempts: maxCheckAttempts, checked: true},
+		// Failures don't keep other versions from being checked
+		{version: "github.com/foo/bar@v2.0.0", attempts: 0, checked: true},
+	}
+	for _, tt := range tests {
+		a := repositories.attempts[tt.version]
+		if a.Count != tt.attempts || repositories.checked[tt.version] != tt.checked {
+			t.Errorf("%s: expected %d attempts and checked %t, got %d attempts and checked %t",
+				tt.version, tt.attempts, tt.checked, a.Count, repositories.checked[tt.version])
+		}
+		if a.Count > 0 && (a.Error == "" || a.Next.IsZero()) {
+			t.Errorf("%s: expected the failure to be recorded, got %+v", tt.version, a)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gobuffalo/packr"
+)
+
+// DetectedLicense is a license found in a file of a module version.
+// Confidence is the share of the license's text found in the file,
+// SPDX is empty if the file doesn't match any known license.
+type DetectedLicense struct {
+	File       string  `json:"file"`
+	SPDX       string  `json:"spdx"`
+	Confidence float64 `json:"confidence"`
+}
+
+// minLicenseConfidence is the share of a license's text a file needs to contain to match
+const minLicenseConfidence = 0.75
+
+// bundledLicenses are the SPDX IDs of the license texts in the licenses directory
+var bundledLicenses = []string{
+	"Apache-2.0",
+	"BSD-2-Clause",
+	"BSD-3-Clause",
+	"CC0-1.0",
+	"GPL-2.0",
+	"GPL-3.0",
+	"ISC",
+	"LGPL-2.1",
+	"LGPL-3.0",
+	"MIT",
+	"MPL-2.0",
+	"Unlicense",
+}
+
+type licenseTemplate struct {
+	SPDX    string
+	Bigrams map[string]bool
+}
+
+var (
+	licenseTemplatesOnce sync.Once
+	licenseTemplates     []licenseTemplate
+)
+
+// loadLicenseTemplates returns the bundled license texts, they're only loaded once
+func loadLicenseTemplates() []licenseTemplate {
+	licenseTemplatesOnce.Do(func() {
+		box := packr.NewBox("./licenses")
+		for _, id := range bundledLicenses {
+			licenseTemplates = append(licenseTemplates, licenseTemplate{
+				SPDX:    id,
+				Bigrams: licenseBigrams(box.String(id + ".txt")),
+			})
+		}
+	})
+	return licenseTemplates
+}
+
+// licenseBigrams returns all pairs of consecutive words of a license text.
+// Copyright lines are left out, as well as punctuation and the case of words,
+// so that only the license's terms are compared.
+func licenseBigrams(text string) map[string]bool {
+	var words []string
+	for _, line := range strings.Split(strings.ToLower(text), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "copyright") {
+			continue
+		}
+		words = append(words, strings.FieldsFunc(line, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})...)
+	}
+
+	bigrams := map[string]bool{}
+	for i := 1; i < len(words); i++ {
+		bigrams[words[i-1]+" "+words[i]] = true
+	}
+	return bigrams
+}
+
+// classifyLicense returns the licenses contained in a text. A text can contain
+// multiple licenses, but a license is only detected if most of its text isn't
+// part of an already detected license too, like BSD-2-Clause is of BSD-3-Clause.
+// Without a match the highest confidence of any license is returned with an empty SPDX ID.
+func classifyLicense(text string) []DetectedLicense {
+	bigrams := licenseBigrams(text)
+
+	type candidate struct {
+		licenseTemplate
+		Confidence float64
+		Score      float64
+	}
+	var candidates []candidate
+	highest := 0.0
+	for _, t := range loadLicenseTemplates() {
+		if len(t.Bigrams) == 0 {
+			continue
+		}
+		found := 0
+		for b := range t.Bigrams {
+			if bigrams[b] {
+				found++
+			}
+		}
+		confidence := float64(found) / float64(len(t.Bigrams))
+		if confidence > highest {
+			highest = confidence
+		}
+		if confidence >= minLicenseConfidence {
+			// Longer licenses that are contained completely come first
+			candidates = append(candidates, candidate{licenseTemplate: t, Confidence: confidence, Score: float64(found) * confidence})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	var detected []DetectedLicense
+	matched := map[string]bool{}
+	for _, c := range candidates {
+		novel := 0
+		for b := range c.Bigrams {
+			if bigrams[b] && !matched[b] {
+				novel++
+			}
+		}
+		if float64(novel)/float64(len(c.Bigrams)) < 0.5 {
+			continue
+		}
+
+		detected = append(detected, DetectedLicense{SPDX: c.SPDX, Confidence: c.Confidence})
+		for b := range c.Bigrams {
+			matched[b] = true
+		}
+	}
+
+	if len(detected) == 0 {
+		return []DetectedLicense{{Confidence: highest}}
+	}
+	return detected
+}
+
+// detectLicenses classifies all license files in a module's root
+func detectLicenses(files []moduleFile) ([]DetectedLicense, error) {
+	names := map[string]bool{}
+	for _, name := range licenseFiles(files) {
+		names[name] = true
+	}
+
+	var detected []DetectedLicense
+	for _, f := range files {
+		if !names[f.Name] {
+			continue
+		}
+
+		rc, err := f.File.Open()
+		if err != nil {
+			return nil, err
+		}
+		text, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, l := range classifyLicense(string(text)) {
+			l.File = f.Name
+			detected = append(detected, l)
+		}
+	}
+
+	sort.SliceStable(detected, func(i, j int) bool {
+		return detected[i].File < detected[j].File
+	})
+
+	return detected, nil
+}
+
+// licenseExpression combines the detected licenses to an SPDX expression.
+// As it's unknown whether multiple licenses apply together or are alternatives,
+// they are combined with AND to not underestimate their obligations.
+func licenseExpression(detected []DetectedLicense) string {
+	ids := map[string]bool{}
+	for _, l := range detected {
+		if l.SPDX != "" {
+			ids[l.SPDX] = true
+		}
+	}
+	// The LGPL-3.0 is a set of additional permissions to the GPL-3.0,
+	// which has to be distributed along with it.
+	if ids["LGPL-3.0"] {
+		delete(ids, "GPL-3.0")
+	}
+
+	var expr []string
+	for id := range ids {
+		expr = append(expr, id)
+	}
+	sort.Strings(expr)
+
+	return strings.Join(expr, " AND ")
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func testLicenseText(t *testing.T, id string) string {
+	text, err := ioutil.ReadFile("licenses/" + id + ".txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(text)
+}
+
+func TestClassifyLicense(t *testing.T) {
+	mit := strings.Replace(testLicenseText(t, "MIT"), "<year> <copyright holders>", "2018 Jane Doe", 1)
+	bsd2 := strings.Replace(testLicenseText(t, "BSD-2-Clause"), "<year>, <copyright holders>", "2017, ACME Inc.", 1)
+	apache := testLicenseText(t, "Apache-2.0")
+	// Most projects don't include the appendix of the Apache License
+	apache = apache[:strings.Index(apache, "APPENDIX")]
+
+	tests := []struct {
+		name string
+		text string
+		ids  []string
+	}{
+		{name: "mit", text: mit, ids: []string{"MIT"}},
+		{name: "bsd-2-clause", text: bsd2, ids: []string{"BSD-2-Clause"}},
+		{name: "bsd-3-clause", text: testLicenseText(t, "BSD-3-Clause"), ids: []string{"BSD-3-Clause"}},
+		{name: "apache without appendix", text: apache, ids: []string{"Apache-2.0"}},
+		{name: "gpl-3.0", text: testLicenseText(t, "GPL-3.0"), ids: []string{"GPL-3.0"}},
+		{name: "lgpl-3.0", text: testLicenseText(t, "LGPL-3.0"), ids: []string{"LGPL-3.0"}},
+		{name: "dual licensed", text: apache + "\n\n---\n\n" + mit, ids: []string{"Apache-2.0", "MIT"}},
+		{name: "unknown", text: "All code in this repository may only be used to build rockets.", ids: []string{""}},
+	}
+
+	for _, tt := range tests {
+		detected := classifyLicense(tt.text)
+		if len(detected) != len(tt.ids) {
+			t.Errorf("%s: expected %v, got %+v", tt.name, tt.ids, detected)
+			continue
+		}
+		for i, id := range tt.ids {
+			if detected[i].SPDX != id {
+				t.Errorf("%s: expected %s, got %+v", tt.name, id, detected[i])
+			}
+			if id != "" && detected[i].Confidence < minLicenseConfidence {
+				t.Errorf("%s: expected a confidence of at least %v, got %v", tt.name, minLicenseConfidence, detected[i].Confidence)
+			}
+		}
+	}
+}
+
+func TestDetectLicenses(t *testing.T) {
+	zr := testModuleZip(t, "github.com/foo/bar@v1.0.0/", map[string]string{
+		"go.mod":         "module github.com/foo/bar\n",
+		"COPYING":        testLicenseText(t, "GPL-3.0"),
+		"COPYING.LESSER": testLicenseText(t, "LGPL-3.0"),
+		"sub/LICENSE":    testLicenseText(t, "MIT"),
+		"bar.go":         "package bar\n",
+	})
+
+	detected, err := detectLicenses(moduleFiles(zr, "github.com/foo/bar", "v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(detected) != 2 || detected[0].File != "COPYING" || detected[0].SPDX != "GPL-3.0" ||
+		detected[1].File != "COPYING.LESSER" || detected[1].SPDX != "LGPL-3.0" {
+		t.Fatalf("unexpected licenses %+v", detected)
+	}
+	if expr := licenseExpression(detected); expr != "LGPL-3.0" {
+		t.Errorf("expected LGPL-3.0, got %s", expr)
+	}
+}
+
+func TestLicenseExpression(t *testing.T) {
+	detected := []DetectedLicense{
+		{File: "LICENSE-MIT", SPDX: "MIT"},
+		{File: "LICENSE-APACHE", SPDX: "Apache-2.0"},
+		{File: "NOTICE"},
+	}
+	if expr := licenseExpression(detected); expr != "Apache-2.0 AND MIT" {
+		t.Errorf("expected Apache-2.0 AND MIT, got %s", expr)
+	}
+}
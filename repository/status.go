@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"strings"
+	"time"
+)
+
+// Status tells if a Repository shouldn't be adopted anymore,
+// because it's archived, deprecated, retracted or inactive.
+type Status struct {
+	Archived   bool        `json:"archived"`
+	Deprecated string      `json:"deprecated,omitempty"`
+	Successor  string      `json:"successor,omitempty"`
+	Retracted  *Retraction `json:"retracted,omitempty"`
+	Inactive   bool        `json:"inactive"`
+}
+
+// Abandoned returns true if any reason not to adopt the Repository applies
+func (s Status) Abandoned() bool {
+	return s.Archived || s.Deprecated != "" || s.Retracted != nil || s.Inactive
+}
+
+// newStatus returns the status of a repository and the go.mod of its current version.
+// A repository is inactive if nothing was pushed for the inactivity period.
+func newStatus(repo Repository, mod GoMod, inactivity time.Duration, now time.Time) Status {
+	s := Status{
+		Archived:   repo.Archived,
+		Deprecated: mod.Deprecated,
+		Inactive:   !repo.Pushed.IsZero() && now.Sub(repo.Pushed) > inactivity,
+	}
+	if s.Deprecated != "" {
+		s.Successor = deprecationSuccessor(s.Deprecated, mod.Module)
+	}
+
+	for _, r := range mod.Retract {
+		if repo.CurrentVersion.Name != "" && r.retracts(repo.CurrentVersion.Name) {
+			r := r
+			s.Retracted = &r
+			break
+		}
+	}
+
+	return s
+}
+
+// deprecationSuccessor returns the first module path named in a deprecation message,
+// like github.com/foo/baz in "Use github.com/foo/baz instead."
+func deprecationSuccessor(msg, module string) string {
+	for _, f := range strings.Fields(msg) {
+		f = strings.Trim(f, ".,;:()\"'`")
+		f = strings.TrimPrefix(strings.TrimPrefix(f, "https://"), "http://")
+		parts := strings.Split(f, "/")
+		if len(parts) < 2 || !strings.Contains(parts[0], ".") || f == module {
+			continue
+		}
+		return f
+	}
+	return ""
+}
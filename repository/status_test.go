@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewStatus(t *testing.T) {
+	now := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+	inactivity := 365 * day
+
+	repo := Repository{
+		Pushed:         now.Add(-30 * day),
+		CurrentVersion: Version{Name: "v1.1.0"},
+	}
+
+	if s := newStatus(repo, GoMod{Module: "github.com/foo/bar"}, inactivity, now); s.Abandoned() {
+		t.Errorf("expected an active repository not to be abandoned, got %+v", s)
+	}
+
+	repo.Archived = true
+	repo.Pushed = now.Add(-400 * day)
+	mod := GoMod{
+		Module:     "github.com/foo/bar",
+		Deprecated: "Moved to github.com/foo/bar/v2, use https://github.com/foo/baz otherwise.",
+		Retract:    []Retraction{{Low: "v1.0.0", High: "v1.1.0", Rationale: "Broken builds."}},
+	}
+
+	s := newStatus(repo, mod, inactivity, now)
+	if !s.Archived || !s.Inactive || s.Deprecated != mod.Deprecated {
+		t.Errorf("expected an archived, inactive and deprecated status, got %+v", s)
+	}
+	if s.Retracted == nil || s.Retracted.Rationale != "Broken builds." {
+		t.Errorf("expected the current version to be retracted, got %+v", s.Retracted)
+	}
+	if s.Successor != "github.com/foo/bar/v2" {
+		t.Errorf("expected github.com/foo/bar/v2 as successor, got %q", s.Successor)
+	}
+}
+
+func TestDeprecationSuccessor(t *testing.T) {
+	tests := []struct {
+		msg       string
+		successor string
+	}{
+		{msg: "Use github.com/foo/baz instead.", successor: "github.com/foo/baz"},
+		{msg: "See https://golang.org/x/tools.", successor: "golang.org/x/tools"},
+		{msg: "This module (github.com/foo/bar) is unmaintained.", successor: ""},
+		{msg: "unmaintained", successor: ""},
+	}
+
+	for _, tt := range tests {
+		if successor := deprecationSuccessor(tt.msg, "github.com/foo/bar"); successor != tt.successor {
+			t.Errorf("expected successor %q of %q, got %q", tt.successor, tt.msg, successor)
+		}
+	}
+}
@@ -3,11 +3,16 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
+// sidebarVersions is the number of versions returned as part of a Repository
+const sidebarVersions = 25
+
 type postgres struct {
 	db *sql.DB
 }
@@ -21,14 +26,24 @@ func (p *postgres) Get(ctx context.Context, url string) (Repository, error) {
 	var r Repository
 	var id string
 	{
-		q := "SELECT id, url, description, updated FROM repositories " +
+		q := "SELECT id, url, description, created, updated, pushed, archived, incomplete, license_name, license_spdx, license_url, health FROM repositories " +
 			"WHERE url = $1 LIMIT 1;"
 		row := p.db.QueryRowContext(ctx, q, url)
 
-		err := row.Scan(&id, &r.URL, &r.Description, &r.Updated)
+		var pushed *time.Time
+		var health []byte
+		err := row.Scan(&id, &r.URL, &r.Description, &r.Created, &r.Updated, &pushed, &r.Archived, pq.Array(&r.Incomplete), &r.License.Name, &r.License.SPDX, &r.License.URL, &health)
 		if err != nil && err.Error() == "sql: no rows in result set" {
 			return r, ErrNotFound
 		}
+		if pushed != nil {
+			r.Pushed = *pushed
+		}
+		if health != nil {
+			if err := json.Unmarshal(health, &r.Health); err != nil {
+				return r, errors.Wrap(err, "failed to decode repository health")
+			}
+		}
 	}
 
 	// Fetch all repository statistics
@@ -52,167 +67,1298 @@ func (p *postgres) Get(ctx context.Context, url string) (Repository, error) {
 			return r, errors.Wrap(err, "failed to retrieve repository statistics")
 		}
 	}
-	// Fetch all repository versions
+	// Fetch all modules of the repository's major versions
 	{
-		q := "SELECT name, published FROM versions WHERE repository_id = $1 ORDER BY sort_order DESC LIMIT 25"
+		q := "SELECT major, path, version FROM modules " +
+			"WHERE repository_id = $1 ORDER BY major ASC;"
 		rows, err := p.db.QueryContext(ctx, q, id)
 		if err != nil {
-			return r, errors.Wrap(err, "failed to fetch repository versions")
+			return r, errors.Wrap(err, "failed to fetch repository modules")
 		}
 		defer rows.Close()
 
 		for rows.Next() {
-			var published *time.Time
-			v := Version{}
-			if err := rows.Scan(&v.Name, &published); err != nil {
-				return r, errors.Wrap(err, "failed to scan repository version")
-			}
-			if published != nil {
-				v.Published = *published
+			m := Module{}
+			if err := rows.Scan(&m.Major, &m.Path, &m.Version); err != nil {
+				return r, errors.Wrap(err, "failed to scan repository module")
 			}
-			r.Versions = append(r.Versions, v)
+			r.Modules = append(r.Modules, m)
 		}
 		if err := rows.Err(); err != nil {
-			return r, errors.Wrap(err, "failed to retrieve repository versions")
+			return r, errors.Wrap(err, "failed to retrieve repository modules")
+		}
+	}
+	// Fetch the latest repository versions
+	{
+		versions, count, err := p.GetVersions(ctx, url, sidebarVersions, 0)
+		if err != nil {
+			return r, err
 		}
+		r.Versions = versions
+		r.VersionsCount = count
+	}
+	// Fetch the current version, which may be older than the latest versions.
+	// Versions that aren't semantic versions are sorted first, so they are only
+	// current if there is no stable semantic version, like currentVersion does.
+	{
+		q := `SELECT name, published, prerelease, draft, breaking, retracted, retraction FROM versions
+			WHERE repository_id = $1 AND NOT draft AND NOT prerelease AND NOT retracted
+			ORDER BY sort_order DESC LIMIT 1`
 
-		if len(r.Versions) > 0 {
-			r.CurrentVersion = r.Versions[0]
+		var published *time.Time
+		v := Version{}
+		err := p.db.QueryRowContext(ctx, q, id).Scan(&v.Name, &published, &v.Prerelease, &v.Draft, &v.Breaking, &v.Retracted, &v.Retraction)
+		if err != nil && err != sql.ErrNoRows {
+			return r, errors.Wrap(err, "failed to fetch current repository version")
+		}
+		if published != nil {
+			v.Published = *published
+		}
+		if err == nil {
+			r.CurrentVersion = v
 		}
 	}
 
 	return r, nil
 }
 
-func (p *postgres) GetPopular(ctx context.Context, limit int) ([]string, error) {
-	q := `SELECT repositories.url
-		FROM repositories LEFT JOIN statistics ON repositories.id = statistics.repository_id
-		WHERE statistics.name = 'Stars' ORDER BY statistics.value DESC LIMIT $1`
-	rows, err := p.db.QueryContext(ctx, q, limit)
+func (p *postgres) GetVersions(ctx context.Context, url string, limit, offset int) ([]Version, int, error) {
+	var count int
+	{
+		q := `SELECT count(*) FROM versions
+			JOIN repositories ON repositories.id = versions.repository_id
+			WHERE repositories.url = $1`
+		if err := p.db.QueryRowContext(ctx, q, url).Scan(&count); err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count repository versions")
+		}
+	}
+
+	q := `SELECT versions.name, versions.published, versions.prerelease, versions.draft, versions.breaking, versions.retracted, versions.retraction FROM versions
+		JOIN repositories ON repositories.id = versions.repository_id
+		WHERE repositories.url = $1
+		ORDER BY versions.sort_order DESC LIMIT $2 OFFSET $3`
+	rows, err := p.db.QueryContext(ctx, q, url, limit, offset)
 	if err != nil {
-		return []string{}, errors.Wrap(err, "failed to query popular repositories")
+		return nil, count, errors.Wrap(err, "failed to fetch repository versions")
 	}
 	defer rows.Close()
 
-	var repos []string
+	var versions []Version
 	for rows.Next() {
-		var r string
-		rows.Scan(&r)
-		repos = append(repos, r)
+		var published *time.Time
+		v := Version{}
+		if err := rows.Scan(&v.Name, &published, &v.Prerelease, &v.Draft, &v.Breaking, &v.Retracted, &v.Retraction); err != nil {
+			return versions, count, errors.Wrap(err, "failed to scan repository version")
+		}
+		if published != nil {
+			v.Published = *published
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return versions, count, errors.Wrap(err, "failed to retrieve repository versions")
 	}
 
-	return repos, nil
+	return versions, count, nil
+}
 
+func (p *postgres) GetVersion(ctx context.Context, url string, name string) (Version, error) {
+	q := `SELECT versions.name, versions.published, versions.prerelease, versions.draft, versions.breaking, versions.retracted, versions.retraction FROM versions
+		JOIN repositories ON repositories.id = versions.repository_id
+		WHERE repositories.url = $1 AND versions.name = $2 LIMIT 1`
+
+	var published *time.Time
+	v := Version{}
+	err := p.db.QueryRowContext(ctx, q, url, name).Scan(&v.Name, &published, &v.Prerelease, &v.Draft, &v.Breaking, &v.Retracted, &v.Retraction)
+	if err == sql.ErrNoRows {
+		return v, ErrNotFound
+	}
+	if err != nil {
+		return v, errors.Wrap(err, "failed to fetch repository version")
+	}
+	if published != nil {
+		v.Published = *published
+	}
+
+	return v, nil
 }
 
-func (p *postgres) GetLatest(ctx context.Context, limit int) ([]string, error) {
-	q := `SELECT url FROM repositories ORDER BY updated DESC LIMIT $1`
-	rows, err := p.db.QueryContext(ctx, q, limit)
+// GetAnalysisAttempt returns the failed attempts to analyze a version
+func (p *postgres) GetAnalysisAttempt(ctx context.Context, url string, name string) (Attempt, error) {
+	q := `SELECT versions.analysis_attempts, versions.analysis_next, versions.analysis_error FROM versions
+		JOIN repositories ON repositories.id = versions.repository_id
+		WHERE repositories.url = $1 AND versions.name = $2 LIMIT 1`
+
+	var (
+		a       Attempt
+		next    *time.Time
+		message sql.NullString
+	)
+	err := p.db.QueryRowContext(ctx, q, url, name).Scan(&a.Count, &next, &message)
+	if err == sql.ErrNoRows {
+		return a, ErrNotFound
+	}
 	if err != nil {
-		return []string{}, errors.Wrap(err, "failed to query latest repositories")
+		return a, errors.Wrap(err, "failed to fetch analysis attempt")
+	}
+	if next != nil {
+		a.Next = *next
+	}
+	a.Error = message.String
+
+	return a, nil
+}
+
+func (p *postgres) SetAnalysisAttempt(ctx context.Context, url string, name string, a Attempt) error {
+	q := `UPDATE versions SET analysis_attempts = $3, analysis_next = $4, analysis_error = $5
+		FROM repositories WHERE repositories.id = versions.repository_id
+		AND repositories.url = $1 AND versions.name = $2`
+	if _, err := p.db.ExecContext(ctx, q, url, name, a.Count, a.Next, a.Error); err != nil {
+		return errors.Wrap(err, "failed to update analysis attempt")
+	}
+	return nil
+}
+
+func (p *postgres) GetVersionRange(ctx context.Context, url string, from, to string) ([]Version, error) {
+	q := `SELECT v.name, v.published, v.prerelease, v.draft, v.breaking, v.retracted, v.retraction, v.notes FROM versions v
+		JOIN repositories r ON r.id = v.repository_id
+		WHERE r.url = $1
+		AND v.sort_order > (SELECT sort_order FROM versions WHERE repository_id = r.id AND name = $2)
+		AND v.sort_order <= (SELECT sort_order FROM versions WHERE repository_id = r.id AND name = $3)
+		ORDER BY v.sort_order DESC`
+	rows, err := p.db.QueryContext(ctx, q, url, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch repository version range")
 	}
 	defer rows.Close()
 
-	var repos []string
+	var versions []Version
 	for rows.Next() {
-		var r string
-		rows.Scan(&r)
-		repos = append(repos, r)
+		var published *time.Time
+		v := Version{}
+		if err := rows.Scan(&v.Name, &published, &v.Prerelease, &v.Draft, &v.Breaking, &v.Retracted, &v.Retraction, &v.Notes); err != nil {
+			return versions, errors.Wrap(err, "failed to scan repository version")
+		}
+		if published != nil {
+			v.Published = *published
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return versions, errors.Wrap(err, "failed to retrieve repository version range")
 	}
 
-	return repos, nil
+	return versions, nil
 }
 
-func (p *postgres) GetRandom(ctx context.Context, limit int) ([]string, error) {
-	q := `SELECT url FROM repositories ORDER BY random() LIMIT $1`
-	rows, err := p.db.QueryContext(ctx, q, limit)
+// GetReleases returns the latest published versions of a repository,
+// or of all repositories if the url is empty.
+func (p *postgres) GetReleases(ctx context.Context, url string, limit int) ([]Release, error) {
+	q := `SELECT r.url, v.name, v.published, v.prerelease, v.draft, v.breaking, v.retracted, v.retraction, v.notes FROM versions v
+		JOIN repositories r ON r.id = v.repository_id
+		WHERE ($1 = '' OR r.url = $1) AND v.published IS NOT NULL AND NOT v.draft
+		ORDER BY v.published DESC LIMIT $2`
+	rows, err := p.db.QueryContext(ctx, q, url, limit)
 	if err != nil {
-		return []string{}, errors.Wrap(err, "failed to query random repositories")
+		return nil, errors.Wrap(err, "failed to fetch releases")
 	}
 	defer rows.Close()
 
-	var repos []string
+	var releases []Release
 	for rows.Next() {
-		var r string
-		rows.Scan(&r)
-		repos = append(repos, r)
+		r := Release{}
+		if err := rows.Scan(&r.URL, &r.Name, &r.Published, &r.Prerelease, &r.Draft, &r.Breaking, &r.Retracted, &r.Retraction, &r.Notes); err != nil {
+			return releases, errors.Wrap(err, "failed to scan release")
+		}
+		releases = append(releases, r)
+	}
+	if err := rows.Err(); err != nil {
+		return releases, errors.Wrap(err, "failed to retrieve releases")
 	}
 
-	return repos, nil
+	return releases, nil
 }
 
-func (p *postgres) Exists(ctx context.Context, url string) (bool, error) {
-	q := `SELECT url FROM repositories WHERE url = $1 LIMIT 1`
-	row := p.db.QueryRowContext(ctx, q, url)
+// GetUncheckedVersions returns versions that weren't checked yet and are due to be checked before a time.
+// The previous version is looked up only for these, instead of scanning all versions.
+func (p *postgres) GetUncheckedVersions(ctx context.Context, before time.Time, limit int) ([]VersionCheck, error) {
+	q := `SELECT r.url, v.name, v.check_attempts, v.check_next, v.check_error,
+			(SELECT prev.name FROM versions prev
+				WHERE prev.repository_id = v.repository_id AND prev.sort_order < v.sort_order
+				AND NOT prev.prerelease AND NOT prev.draft
+				ORDER BY prev.sort_order DESC LIMIT 1) AS previous
+		FROM versions v JOIN repositories r ON r.id = v.repository_id
+		WHERE NOT v.api_checked AND NOT v.prerelease AND NOT v.draft
+		AND (v.check_next IS NULL OR v.check_next <= $1)
+		ORDER BY v.sort_order ASC LIMIT $2`
+	rows, err := p.db.QueryContext(ctx, q, before, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch unchecked versions")
+	}
+	defer rows.Close()
 
-	var u string
-	err := row.Scan(&u)
-	if err != nil && err.Error() != "sql: no rows in result set" {
-		return false, err
+	var checks []VersionCheck
+	for rows.Next() {
+		var (
+			next     *time.Time
+			message  sql.NullString
+			previous sql.NullString
+		)
+		c := VersionCheck{}
+		if err := rows.Scan(&c.URL, &c.Version, &c.Attempt.Count, &next, &message, &previous); err != nil {
+			return checks, errors.Wrap(err, "failed to scan unchecked version")
+		}
+		if next != nil {
+			c.Attempt.Next = *next
+		}
+		c.Attempt.Error = message.String
+		c.Previous = previous.String
+		checks = append(checks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return checks, errors.Wrap(err, "failed to retrieve unchecked versions")
 	}
 
-	return url == u, nil
+	return checks, nil
 }
 
-func (p *postgres) Create(ctx context.Context, repo Repository) error {
+func (p *postgres) SetCheckAttempt(ctx context.Context, url string, name string, a Attempt) error {
+	q := `UPDATE versions SET check_attempts = $3, check_next = $4, check_error = $5
+		FROM repositories WHERE repositories.id = versions.repository_id
+		AND repositories.url = $1 AND versions.name = $2`
+	if _, err := p.db.ExecContext(ctx, q, url, name, a.Count, a.Next, a.Error); err != nil {
+		return errors.Wrap(err, "failed to update check attempt")
+	}
+	return nil
+}
+
+func (p *postgres) SetVersionBreaking(ctx context.Context, url string, name string, breaking bool) error {
+	q := `UPDATE versions SET breaking = $3, api_checked = TRUE
+		FROM repositories WHERE repositories.id = versions.repository_id
+		AND repositories.url = $1 AND versions.name = $2`
+	if _, err := p.db.ExecContext(ctx, q, url, name, breaking); err != nil {
+		return errors.Wrap(err, "failed to update version")
+	}
+	return nil
+}
+
+func (p *postgres) GetCompatibility(ctx context.Context, url string, from, to string) (Compatibility, error) {
+	q := `SELECT c.compatibility FROM compatibilities c
+		JOIN repositories r ON r.id = c.repository_id
+		WHERE r.url = $1 AND c.from_version = $2 AND c.to_version = $3`
+
+	var c Compatibility
+	var data []byte
+	err := p.db.QueryRowContext(ctx, q, url, from, to).Scan(&data)
+	if err == sql.ErrNoRows {
+		return c, ErrNotFound
+	}
+	if err != nil {
+		return c, errors.Wrap(err, "failed to fetch compatibility")
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, errors.Wrap(err, "failed to decode compatibility")
+	}
+
+	return c, nil
+}
+
+func (p *postgres) CreateCompatibility(ctx context.Context, url string, c Compatibility) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode compatibility")
+	}
+
+	q := `INSERT INTO compatibilities (repository_id, from_version, to_version, compatibility)
+		SELECT id, $2, $3, $4 FROM repositories WHERE url = $1
+		ON CONFLICT (repository_id, from_version, to_version) DO NOTHING`
+	if _, err := p.db.ExecContext(ctx, q, url, c.From, c.To, data); err != nil {
+		return errors.Wrap(err, "failed to insert compatibility")
+	}
+	return nil
+}
+
+func (p *postgres) GetModuleVersion(ctx context.Context, path, version string) (ModuleVersion, error) {
+	mv := ModuleVersion{Path: path, Version: version}
+	{
+		q := `SELECT gomod, packages, license_files, build_list_resolved, licenses_detected, tests FROM module_versions WHERE path = $1 AND version = $2`
+
+		var packages []byte
+		err := p.db.QueryRowContext(ctx, q, path, version).Scan(&mv.GoMod, &packages, pq.Array(&mv.LicenseFiles), &mv.BuildListResolved, &mv.LicensesDetected, &mv.Tests)
+		if err == sql.ErrNoRows {
+			return mv, ErrNotFound
+		}
+		if err != nil {
+			return mv, errors.Wrap(err, "failed to fetch module version")
+		}
+		if packages != nil {
+			if err := json.Unmarshal(packages, &mv.Packages); err != nil {
+				return mv, errors.Wrap(err, "failed to decode module version packages")
+			}
+		}
+	}
+	// Fetch all dependencies of the module version
+	{
+		q := `SELECT path, required, indirect FROM dependencies WHERE module = $1 AND version = $2 ORDER BY path ASC`
+		rows, err := p.db.QueryContext(ctx, q, path, version)
+		if err != nil {
+			return mv, errors.Wrap(err, "failed to fetch module version dependencies")
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			d := Dependency{}
+			if err := rows.Scan(&d.Path, &d.Version, &d.Indirect); err != nil {
+				return mv, errors.Wrap(err, "failed to scan module version dependency")
+			}
+			mv.Dependencies = append(mv.Dependencies, d)
+		}
+		if err := rows.Err(); err != nil {
+			return mv, errors.Wrap(err, "failed to retrieve module version dependencies")
+		}
+	}
+	// Fetch the build list of the module version
+	if mv.BuildListResolved {
+		q := `SELECT path, selected, indirect, replace FROM build_lists WHERE module = $1 AND version = $2 ORDER BY path ASC`
+		rows, err := p.db.QueryContext(ctx, q, path, version)
+		if err != nil {
+			return mv, errors.Wrap(err, "failed to fetch module version build list")
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			d := Dependency{}
+			var replace sql.NullString
+			if err := rows.Scan(&d.Path, &d.Version, &d.Indirect, &replace); err != nil {
+				return mv, errors.Wrap(err, "failed to scan module version build list")
+			}
+			d.Replace = replace.String
+			mv.BuildList = append(mv.BuildList, d)
+		}
+		if err := rows.Err(); err != nil {
+			return mv, errors.Wrap(err, "failed to retrieve module version build list")
+		}
+	}
+	// Fetch the detected licenses of the module version
+	if mv.LicensesDetected {
+		licenses, err := p.GetModuleLicenses(ctx, []Dependency{{Path: path, Version: version}})
+		if err != nil {
+			return mv, err
+		}
+		mv.Licenses = licenses[graphID(path, version)]
+	}
+
+	return mv, nil
+}
+
+func (p *postgres) SetBuildList(ctx context.Context, path, version string, list []Dependency) error {
 	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
 		return errors.Wrap(err, "failed to create transaction")
 	}
 
-	var id string
 	{
-		q := `INSERT INTO repositories (url, description, updated) VALUES ($1, $2, $3) RETURNING id`
-		row := tx.QueryRowContext(ctx, q, repo.URL, repo.Description, repo.Updated)
-
-		if err := row.Scan(&id); err != nil {
+		q := `DELETE FROM build_lists WHERE module = $1 AND version = $2`
+		if _, err := tx.ExecContext(ctx, q, path, version); err != nil {
 			tx.Rollback()
-			return errors.Wrap(err, "failed to scan repository id")
+			return errors.Wrap(err, "failed to delete build list")
 		}
 	}
-
-	// statistics
 	{
-		q := `INSERT INTO statistics (repository_id, name, value, url) VALUES ($1, $2, $3, $4)`
+		q := `INSERT INTO build_lists (module, version, path, selected, indirect, replace) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))`
 		stmt, err := tx.PrepareContext(ctx, q)
 		if err != nil {
-			return errors.Wrap(err, "failed to prepare the inserting statistics query")
+			tx.Rollback()
+			return errors.Wrap(err, "failed to prepare the inserting build list query")
 		}
 		defer stmt.Close()
 
-		for _, stat := range repo.Statistics {
-			if _, err := stmt.ExecContext(ctx, id, stat.Name, stat.Value, stat.URL); err != nil {
+		for _, d := range list {
+			if _, err := stmt.ExecContext(ctx, path, version, d.Path, d.Version, d.Indirect, d.Replace); err != nil {
 				tx.Rollback()
-				return errors.Wrap(err, "failed to insert repository stat")
+				return errors.Wrap(err, "failed to insert build list dependency")
 			}
 		}
 	}
+	{
+		q := `UPDATE module_versions SET build_list_resolved = TRUE WHERE path = $1 AND version = $2`
+		if _, err := tx.ExecContext(ctx, q, path, version); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to update module version")
+		}
+	}
 
-	// versions
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// GetModuleLicenses returns the detected licenses of module versions by their graphID
+func (p *postgres) GetModuleLicenses(ctx context.Context, modules []Dependency) (map[string][]DetectedLicense, error) {
+	var paths, versions []string
+	for _, m := range modules {
+		paths = append(paths, m.Path)
+		versions = append(versions, m.Version)
+	}
+
+	q := `SELECT module_licenses.path, module_licenses.version, file, spdx, confidence
+		FROM module_licenses JOIN unnest($1::VARCHAR[], $2::VARCHAR[]) AS m (path, version)
+		ON module_licenses.path = m.path AND module_licenses.version = m.version
+		ORDER BY file ASC, confidence DESC`
+	rows, err := p.db.QueryContext(ctx, q, pq.Array(paths), pq.Array(versions))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch module licenses")
+	}
+	defer rows.Close()
+
+	licenses := map[string][]DetectedLicense{}
+	for rows.Next() {
+		var path, version string
+		l := DetectedLicense{}
+		if err := rows.Scan(&path, &version, &l.File, &l.SPDX, &l.Confidence); err != nil {
+			return licenses, errors.Wrap(err, "failed to scan module license")
+		}
+		licenses[graphID(path, version)] = append(licenses[graphID(path, version)], l)
+	}
+	if err := rows.Err(); err != nil {
+		return licenses, errors.Wrap(err, "failed to retrieve module licenses")
+	}
+
+	return licenses, nil
+}
+
+func (p *postgres) SetModuleLicenses(ctx context.Context, path, version string, licenses []DetectedLicense) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transaction")
+	}
+
+	{
+		q := `DELETE FROM module_licenses WHERE path = $1 AND version = $2`
+		if _, err := tx.ExecContext(ctx, q, path, version); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to delete module licenses")
+		}
+	}
+	if err := insertModuleLicenses(ctx, tx, path, version, licenses); err != nil {
+		tx.Rollback()
+		return err
+	}
+	{
+		q := `UPDATE module_versions SET licenses_detected = TRUE WHERE path = $1 AND version = $2`
+		if _, err := tx.ExecContext(ctx, q, path, version); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to update module version")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+func insertModuleLicenses(ctx context.Context, tx *sql.Tx, path, version string, licenses []DetectedLicense) error {
+	q := `INSERT INTO module_licenses (path, version, file, spdx, confidence) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (path, version, file, spdx) DO NOTHING`
+	stmt, err := tx.PrepareContext(ctx, q)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare the inserting module licenses query")
+	}
+	defer stmt.Close()
+
+	for _, l := range licenses {
+		if _, err := stmt.ExecContext(ctx, path, version, l.File, l.SPDX, l.Confidence); err != nil {
+			return errors.Wrap(err, "failed to insert module license")
+		}
+	}
+	return nil
+}
+
+func (p *postgres) GetGoMod(ctx context.Context, path, version string) (string, error) {
+	q := `SELECT gomod FROM gomods WHERE path = $1 AND version = $2`
+
+	var gomod string
+	err := p.db.QueryRowContext(ctx, q, path, version).Scan(&gomod)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "failed to fetch go.mod")
+	}
+
+	return gomod, nil
+}
+
+func (p *postgres) CreateGoMod(ctx context.Context, path, version, gomod string) error {
+	q := `INSERT INTO gomods (path, version, gomod) VALUES ($1, $2, $3) ON CONFLICT (path, version) DO NOTHING`
+	if _, err := p.db.ExecContext(ctx, q, path, version, gomod); err != nil {
+		return errors.Wrap(err, "failed to insert go.mod")
+	}
+	return nil
+}
+
+func (p *postgres) CreateModuleVersion(ctx context.Context, mv ModuleVersion) error {
+	packages, err := json.Marshal(mv.Packages)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode module version packages")
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transaction")
+	}
+
+	{
+		q := `INSERT INTO module_versions (path, version, gomod, packages, license_files, licenses_detected, tests) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (path, version) DO NOTHING`
+		if _, err := tx.ExecContext(ctx, q, mv.Path, mv.Version, mv.GoMod, packages, pq.Array(mv.LicenseFiles), mv.LicensesDetected, mv.Tests); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to insert module version")
+		}
+	}
+
+	// dependencies
 	{
-		q := `INSERT INTO versions (repository_id, name, sort_order, published) VALUES ($1, $2, $3, $4)`
+		q := `INSERT INTO dependencies (module, version, path, required, indirect) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (module, version, path) DO NOTHING`
 		stmt, err := tx.PrepareContext(ctx, q)
 		if err != nil {
-			return errors.Wrap(err, "failed to prepare the inserting versions query")
+			tx.Rollback()
+			return errors.Wrap(err, "failed to prepare the inserting dependencies query")
 		}
 		defer stmt.Close()
 
-		for i, v := range repo.Versions {
-			var published *time.Time
-			if !v.Published.IsZero() {
-				published = &v.Published
-			}
-
-			if _, err := stmt.ExecContext(ctx, id, v.Name, i, published); err != nil {
+		for _, d := range mv.Dependencies {
+			if _, err := stmt.ExecContext(ctx, mv.Path, mv.Version, d.Path, d.Version, d.Indirect); err != nil {
 				tx.Rollback()
-				return errors.Wrap(err, "failed to insert repository versions")
+				return errors.Wrap(err, "failed to insert module version dependency")
 			}
 		}
 	}
 
+	if err := insertModuleLicenses(ctx, tx, mv.Path, mv.Version, mv.Licenses); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return errors.Wrap(err, "failed to commit transaction")
 	}
 
 	return nil
 }
+
+func (p *postgres) GetPopular(ctx context.Context, limit int) ([]string, error) {
+	q := `SELECT repositories.url
+		FROM repositories LEFT JOIN statistics ON repositories.id = statistics.repository_id
+		WHERE statistics.name = 'Stars' ORDER BY statistics.value DESC LIMIT $1`
+	rows, err := p.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to query popular repositories")
+	}
+	defer rows.Close()
+
+	var repos []string
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return repos, errors.Wrap(err, "failed to scan popular repository")
+		}
+		repos = append(repos, r)
+	}
+	if err := rows.Err(); err != nil {
+		return repos, errors.Wrap(err, "failed to retrieve popular repositories")
+	}
+
+	return repos, nil
+}
+
+func (p *postgres) GetHealthiest(ctx context.Context, limit int) ([]string, error) {
+	q := `SELECT url FROM repositories WHERE health_score IS NOT NULL ORDER BY health_score DESC, url ASC LIMIT $1`
+	rows, err := p.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to query healthiest repositories")
+	}
+	defer rows.Close()
+
+	var repos []string
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return repos, errors.Wrap(err, "failed to scan healthiest repository")
+		}
+		repos = append(repos, r)
+	}
+	if err := rows.Err(); err != nil {
+		return repos, errors.Wrap(err, "failed to retrieve healthiest repositories")
+	}
+
+	return repos, nil
+}
+
+// GetReleaseActivity returns when the latest version of a repository was published
+// and how many of its versions were published since a time, drafts aren't counted
+func (p *postgres) GetReleaseActivity(ctx context.Context, url string, since time.Time) (ReleaseActivity, error) {
+	q := `SELECT max(v.published), count(*) FILTER (WHERE v.published > $2) FROM versions v
+		JOIN repositories r ON r.id = v.repository_id
+		WHERE r.url = $1 AND NOT v.draft AND v.published IS NOT NULL`
+
+	var a ReleaseActivity
+	var latest *time.Time
+	if err := p.db.QueryRowContext(ctx, q, url, since).Scan(&latest, &a.LastYear); err != nil {
+		return a, errors.Wrap(err, "failed to fetch release activity")
+	}
+	if latest != nil {
+		a.Latest = *latest
+	}
+
+	return a, nil
+}
+
+func (p *postgres) SetHealth(ctx context.Context, url string, health Health) error {
+	data, err := json.Marshal(health)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode repository health")
+	}
+
+	q := `UPDATE repositories SET health_score = $2, health = $3, health_computed = $4 WHERE url = $1`
+	if _, err := p.db.ExecContext(ctx, q, url, health.Score, data, health.Computed); err != nil {
+		return errors.Wrap(err, "failed to update repository health")
+	}
+	return nil
+}
+
+// GetNewest returns the repositories indexed most recently
+func (p *postgres) GetNewest(ctx context.Context, limit int) ([]Repository, error) {
+	q := `SELECT url, description, created FROM repositories ORDER BY created DESC LIMIT $1`
+	rows, err := p.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query newest repositories")
+	}
+	defer rows.Close()
+
+	var repos []Repository
+	for rows.Next() {
+		r := Repository{}
+		if err := rows.Scan(&r.URL, &r.Description, &r.Created); err != nil {
+			return repos, errors.Wrap(err, "failed to scan repository")
+		}
+		repos = append(repos, r)
+	}
+	if err := rows.Err(); err != nil {
+		return repos, errors.Wrap(err, "failed to retrieve newest repositories")
+	}
+
+	return repos, nil
+}
+
+func (p *postgres) GetLatest(ctx context.Context, limit int) ([]string, error) {
+	q := `SELECT url FROM repositories ORDER BY updated DESC LIMIT $1`
+	rows, err := p.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to query latest repositories")
+	}
+	defer rows.Close()
+
+	var repos []string
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return repos, errors.Wrap(err, "failed to scan latest repository")
+		}
+		repos = append(repos, r)
+	}
+	if err := rows.Err(); err != nil {
+		return repos, errors.Wrap(err, "failed to retrieve latest repositories")
+	}
+
+	return repos, nil
+}
+
+// randomSampleThreshold is the estimated number of repositories up to which
+// random repositories are simply sorted randomly instead of being sampled
+const randomSampleThreshold = 10000
+
+// GetRandom returns random repositories without scanning and sorting the whole table.
+// As ids are random UUIDs, the repository with the next id after a random UUID is a random
+// repository, which is found by the primary key's index. Points after the highest id wrap
+// around to the lowest one. Repositories after larger gaps between ids are a bit more likely,
+// and points hitting the same repository are dropped, so twice the limit is sampled.
+// Small tables, where this matters most, are sorted randomly instead.
+func (p *postgres) GetRandom(ctx context.Context, limit int) ([]string, error) {
+	var estimate float64
+	q := `SELECT reltuples FROM pg_class WHERE oid = 'repositories'::regclass`
+	if err := p.db.QueryRowContext(ctx, q).Scan(&estimate); err != nil {
+		return []string{}, errors.Wrap(err, "failed to estimate repositories")
+	}
+
+	// Tables that were never analyzed are estimated as 0 or -1
+	q = `SELECT url FROM repositories ORDER BY random() LIMIT $1`
+	samples := limit
+	if estimate >= randomSampleThreshold {
+		q = `SELECT COALESCE(
+				(SELECT url FROM repositories WHERE id >= points.point ORDER BY id LIMIT 1),
+				(SELECT url FROM repositories ORDER BY id LIMIT 1)
+			) FROM (SELECT gen_random_uuid() AS point FROM generate_series(1, $1)) points`
+		samples = 2 * limit
+	}
+	rows, err := p.db.QueryContext(ctx, q, samples)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to query random repositories")
+	}
+	defer rows.Close()
+
+	var repos []string
+	seen := map[string]bool{}
+	for rows.Next() {
+		var r sql.NullString
+		if err := rows.Scan(&r); err != nil {
+			return repos, errors.Wrap(err, "failed to scan random repository")
+		}
+		if !r.Valid || seen[r.String] || len(repos) == limit {
+			continue
+		}
+		seen[r.String] = true
+		repos = append(repos, r.String)
+	}
+	if err := rows.Err(); err != nil {
+		return repos, errors.Wrap(err, "failed to retrieve random repositories")
+	}
+
+	return repos, nil
+}
+
+func (p *postgres) Exists(ctx context.Context, url string) (bool, error) {
+	q := `SELECT url FROM repositories WHERE url = $1 LIMIT 1`
+	row := p.db.QueryRowContext(ctx, q, url)
+
+	var u string
+	err := row.Scan(&u)
+	if err != nil && err.Error() != "sql: no rows in result set" {
+		return false, err
+	}
+
+	return url == u, nil
+}
+
+func (p *postgres) Create(ctx context.Context, repo Repository) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transaction")
+	}
+
+	var id string
+	{
+		var pushed *time.Time
+		if !repo.Pushed.IsZero() {
+			pushed = &repo.Pushed
+		}
+
+		q := `INSERT INTO repositories (url, description, updated, pushed, archived, incomplete, license_name, license_spdx, license_url)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+		row := tx.QueryRowContext(ctx, q, repo.URL, repo.Description, repo.Updated, pushed, repo.Archived, pq.Array(repo.Incomplete), repo.License.Name, repo.License.SPDX, repo.License.URL)
+
+		if err := row.Scan(&id); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to scan repository id")
+		}
+	}
+
+	// The repository may have been missing before
+	{
+		q := `DELETE FROM missing_repositories WHERE url = $1`
+		if _, err := tx.ExecContext(ctx, q, repo.URL); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to delete missing repository")
+		}
+	}
+
+	// statistics
+	{
+		q := `INSERT INTO statistics (repository_id, name, value, url) VALUES ($1, $2, $3, $4)`
+		stmt, err := tx.PrepareContext(ctx, q)
+		if err != nil {
+			return errors.Wrap(err, "failed to prepare the inserting statistics query")
+		}
+		defer stmt.Close()
+
+		for _, stat := range repo.Statistics {
+			if _, err := stmt.ExecContext(ctx, id, stat.Name, stat.Value, stat.URL); err != nil {
+				tx.Rollback()
+				return errors.Wrap(err, "failed to insert repository stat")
+			}
+		}
+	}
+
+	// versions
+	{
+		q := `INSERT INTO versions (repository_id, name, sort_order, published, prerelease, draft, retracted, retraction, notes)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+		stmt, err := tx.PrepareContext(ctx, q)
+		if err != nil {
+			return errors.Wrap(err, "failed to prepare the inserting versions query")
+		}
+		defer stmt.Close()
+
+		for i, v := range repo.Versions {
+			var published *time.Time
+			if !v.Published.IsZero() {
+				published = &v.Published
+			}
+
+			if _, err := stmt.ExecContext(ctx, id, v.Name, i, published, v.Prerelease, v.Draft, v.Retracted, v.Retraction, v.Notes); err != nil {
+				tx.Rollback()
+				return errors.Wrap(err, "failed to insert repository versions")
+			}
+		}
+	}
+
+	// modules
+	{
+		q := `INSERT INTO modules (repository_id, major, path, version) VALUES ($1, $2, $3, $4)`
+		stmt, err := tx.PrepareContext(ctx, q)
+		if err != nil {
+			return errors.Wrap(err, "failed to prepare the inserting modules query")
+		}
+		defer stmt.Close()
+
+		for _, m := range repo.Modules {
+			if _, err := stmt.ExecContext(ctx, id, m.Major, m.Path, m.Version); err != nil {
+				tx.Rollback()
+				return errors.Wrap(err, "failed to insert repository module")
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+func (p *postgres) GetAdvisories(ctx context.Context, paths []string) ([]Advisory, error) {
+	q := `SELECT advisory FROM advisories WHERE id IN (
+			SELECT advisory_id FROM advisory_modules WHERE path = ANY($1)
+		) ORDER BY id`
+	rows, err := p.db.QueryContext(ctx, q, pq.Array(paths))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch advisories")
+	}
+	defer rows.Close()
+
+	var advisories []Advisory
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return advisories, errors.Wrap(err, "failed to scan advisory")
+		}
+		var a Advisory
+		if err := json.Unmarshal(data, &a); err != nil {
+			return advisories, errors.Wrap(err, "failed to decode advisory")
+		}
+		advisories = append(advisories, a)
+	}
+	if err := rows.Err(); err != nil {
+		return advisories, errors.Wrap(err, "failed to retrieve advisories")
+	}
+
+	return advisories, nil
+}
+
+func (p *postgres) CreateAdvisories(ctx context.Context, advisories []Advisory) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transaction")
+	}
+
+	for _, a := range advisories {
+		data, err := json.Marshal(a)
+		if err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to encode advisory")
+		}
+
+		// Advisories are only updated if they were modified since the last import
+		q := `INSERT INTO advisories (id, modified, advisory) VALUES ($1, $2, $3)
+			ON CONFLICT (id) DO UPDATE SET modified = excluded.modified, advisory = excluded.advisory
+			WHERE advisories.modified < excluded.modified`
+		if _, err := tx.ExecContext(ctx, q, a.ID, a.Modified, data); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to insert advisory")
+		}
+
+		for _, path := range a.Modules() {
+			q := `INSERT INTO advisory_modules (advisory_id, path) VALUES ($1, $2)
+				ON CONFLICT (advisory_id, path) DO NOTHING`
+			if _, err := tx.ExecContext(ctx, q, a.ID, path); err != nil {
+				tx.Rollback()
+				return errors.Wrap(err, "failed to insert advisory module")
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+func (p *postgres) GetLicenses(ctx context.Context, urls []string) (map[string]License, error) {
+	q := `SELECT url, license_name, license_spdx, license_url FROM repositories WHERE url = ANY($1)`
+	rows, err := p.db.QueryContext(ctx, q, pq.Array(urls))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch licenses")
+	}
+	defer rows.Close()
+
+	licenses := map[string]License{}
+	for rows.Next() {
+		var url string
+		l := License{}
+		if err := rows.Scan(&url, &l.Name, &l.SPDX, &l.URL); err != nil {
+			return licenses, errors.Wrap(err, "failed to scan license")
+		}
+		licenses[url] = l
+	}
+	if err := rows.Err(); err != nil {
+		return licenses, errors.Wrap(err, "failed to retrieve licenses")
+	}
+
+	return licenses, nil
+}
+
+// Update replaces the data of an existing repository with data fetched again.
+// Versions are updated in place, so that the results of their API checks are kept.
+func (p *postgres) Update(ctx context.Context, repo Repository) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transaction")
+	}
+
+	var id string
+	{
+		var pushed *time.Time
+		if !repo.Pushed.IsZero() {
+			pushed = &repo.Pushed
+		}
+
+		q := `UPDATE repositories SET description = $2, updated = $3, pushed = $4, archived = $5, incomplete = $6, license_name = $7, license_spdx = $8, license_url = $9
+			WHERE url = $1 RETURNING id`
+		row := tx.QueryRowContext(ctx, q, repo.URL, repo.Description, repo.Updated, pushed, repo.Archived, pq.Array(repo.Incomplete), repo.License.Name, repo.License.SPDX, repo.License.URL)
+
+		err := row.Scan(&id)
+		if err == sql.ErrNoRows {
+			tx.Rollback()
+			return ErrNotFound
+		}
+		if err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to update repository")
+		}
+	}
+
+	// statistics
+	{
+		q := `DELETE FROM statistics WHERE repository_id = $1`
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to delete repository statistics")
+		}
+	}
+	{
+		q := `INSERT INTO statistics (repository_id, name, value, url) VALUES ($1, $2, $3, $4)`
+		stmt, err := tx.PrepareContext(ctx, q)
+		if err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to prepare the inserting statistics query")
+		}
+		defer stmt.Close()
+
+		for _, stat := range repo.Statistics {
+			if _, err := stmt.ExecContext(ctx, id, stat.Name, stat.Value, stat.URL); err != nil {
+				tx.Rollback()
+				return errors.Wrap(err, "failed to insert repository stat")
+			}
+		}
+	}
+
+	// versions
+	{
+		q := `INSERT INTO versions (repository_id, name, sort_order, published, prerelease, draft, retracted, retraction, notes)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (repository_id, name) DO UPDATE SET sort_order = excluded.sort_order, published = excluded.published,
+				prerelease = excluded.prerelease, draft = excluded.draft, retracted = excluded.retracted,
+				retraction = excluded.retraction, notes = excluded.notes`
+		stmt, err := tx.PrepareContext(ctx, q)
+		if err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to prepare the upserting versions query")
+		}
+		defer stmt.Close()
+
+		names := make([]string, len(repo.Versions))
+		for i, v := range repo.Versions {
+			var published *time.Time
+			if !v.Published.IsZero() {
+				published = &v.Published
+			}
+
+			if _, err := stmt.ExecContext(ctx, id, v.Name, i, published, v.Prerelease, v.Draft, v.Retracted, v.Retraction, v.Notes); err != nil {
+				tx.Rollback()
+				return errors.Wrap(err, "failed to upsert repository version")
+			}
+			names[i] = v.Name
+		}
+
+		// Tags deleted on GitHub are removed
+		q = `DELETE FROM versions WHERE repository_id = $1 AND NOT name = ANY($2)`
+		if _, err := tx.ExecContext(ctx, q, id, pq.Array(names)); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to delete repository versions")
+		}
+	}
+
+	// modules
+	{
+		q := `DELETE FROM modules WHERE repository_id = $1`
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to delete repository modules")
+		}
+	}
+	{
+		q := `INSERT INTO modules (repository_id, major, path, version) VALUES ($1, $2, $3, $4)`
+		stmt, err := tx.PrepareContext(ctx, q)
+		if err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to prepare the inserting modules query")
+		}
+		defer stmt.Close()
+
+		for _, m := range repo.Modules {
+			if _, err := stmt.ExecContext(ctx, id, m.Major, m.Path, m.Version); err != nil {
+				tx.Rollback()
+				return errors.Wrap(err, "failed to insert repository module")
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// GetMissing returns the time a repository was found missing last, or the zero time if it never was
+func (p *postgres) GetMissing(ctx context.Context, url string) (time.Time, error) {
+	q := `SELECT checked FROM missing_repositories WHERE url = $1`
+
+	var checked time.Time
+	err := p.db.QueryRowContext(ctx, q, url).Scan(&checked)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return checked, errors.Wrap(err, "failed to fetch missing repository")
+	}
+
+	return checked, nil
+}
+
+func (p *postgres) SetMissing(ctx context.Context, url string, checked time.Time) error {
+	q := `INSERT INTO missing_repositories (url, checked) VALUES ($1, $2)
+		ON CONFLICT (url) DO UPDATE SET checked = excluded.checked`
+	if _, err := p.db.ExecContext(ctx, q, url, checked); err != nil {
+		return errors.Wrap(err, "failed to insert missing repository")
+	}
+	return nil
+}
+
+func (p *postgres) CreateSubscription(ctx context.Context, sub Subscription) (Subscription, error) {
+	q := `INSERT INTO subscriptions (url, format, repositories) VALUES ($1, $2, $3) RETURNING id, created`
+	row := p.db.QueryRowContext(ctx, q, sub.URL, sub.Format, pq.Array(sub.Repositories))
+	if err := row.Scan(&sub.ID, &sub.Created); err != nil {
+		return sub, errors.Wrap(err, "failed to insert subscription")
+	}
+	return sub, nil
+}
+
+// GetSubscription returns a subscription with its latest deliveries
+func (p *postgres) GetSubscription(ctx context.Context, id string) (Subscription, error) {
+	var sub Subscription
+	{
+		// Comparing the id as text doesn't fail for ids that aren't valid uuids
+		q := `SELECT id, url, format, repositories, created FROM subscriptions WHERE id::text = $1`
+		err := p.db.QueryRowContext(ctx, q, id).Scan(&sub.ID, &sub.URL, &sub.Format, pq.Array(&sub.Repositories), &sub.Created)
+		if err == sql.ErrNoRows {
+			return sub, ErrSubscriptionNotFound
+		}
+		if err != nil {
+			return sub, errors.Wrap(err, "failed to fetch subscription")
+		}
+	}
+	// Fetch the delivery log of the subscription
+	{
+		q := `SELECT id, event, status, attempts, next_attempt, response_status, error, created FROM deliveries
+			WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2`
+		rows, err := p.db.QueryContext(ctx, q, sub.ID, subscriptionDeliveries)
+		if err != nil {
+			return sub, errors.Wrap(err, "failed to fetch subscription deliveries")
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var event []byte
+			d := Delivery{Subscription: sub.ID, URL: sub.URL, Format: sub.Format}
+			if err := rows.Scan(&d.ID, &event, &d.Status, &d.Attempts, &d.NextAttempt, &d.ResponseStatus, &d.Error, &d.Created); err != nil {
+				return sub, errors.Wrap(err, "failed to scan subscription delivery")
+			}
+			if err := json.Unmarshal(event, &d.Event); err != nil {
+				return sub, errors.Wrap(err, "failed to decode delivery event")
+			}
+			sub.Deliveries = append(sub.Deliveries, d)
+		}
+		if err := rows.Err(); err != nil {
+			return sub, errors.Wrap(err, "failed to retrieve subscription deliveries")
+		}
+	}
+
+	return sub, nil
+}
+
+func (p *postgres) DeleteSubscription(ctx context.Context, id string) error {
+	q := `DELETE FROM subscriptions WHERE id::text = $1`
+	res, err := p.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete subscription")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// GetWatched returns the repositories watched by subscriptions that weren't updated
+// or checked since a time, the ones checked longest ago first. Repositories that fail
+// to be refreshed are only checked again after the others, so that they can't
+// keep the other repositories from being refreshed.
+// Watched repositories that aren't indexed yet are left out, as only indexed
+// repositories can be subscribed to and these aren't fetched in the background.
+func (p *postgres) GetWatched(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	q := `SELECT w.url FROM (SELECT DISTINCT unnest(repositories) AS url FROM subscriptions) w
+		JOIN repositories r ON r.url = w.url
+		WHERE GREATEST(r.updated, r.watch_checked) < $1
+		ORDER BY GREATEST(r.updated, r.watch_checked) ASC LIMIT $2`
+	rows, err := p.db.QueryContext(ctx, q, before, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch watched repositories")
+	}
+	defer rows.Close()
+
+	var repos []string
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return repos, errors.Wrap(err, "failed to scan watched repository")
+		}
+		repos = append(repos, r)
+	}
+	if err := rows.Err(); err != nil {
+		return repos, errors.Wrap(err, "failed to retrieve watched repositories")
+	}
+
+	return repos, nil
+}
+
+// SetWatchChecked records when a watched repository was last checked
+func (p *postgres) SetWatchChecked(ctx context.Context, url string, checked time.Time) error {
+	q := `UPDATE repositories SET watch_checked = $2 WHERE url = $1`
+	if _, err := p.db.ExecContext(ctx, q, url, checked); err != nil {
+		return errors.Wrap(err, "failed to update watched repository")
+	}
+	return nil
+}
+
+// CreateDeliveries creates a pending delivery of every event for each subscription
+// watching its repository. Subscriptions are only notified about events that happened
+// after they were created and only once about each event.
+func (p *postgres) CreateDeliveries(ctx context.Context, events []Event) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create transaction")
+	}
+
+	q := `INSERT INTO deliveries (subscription_id, key, event)
+		SELECT id, $3, $4 FROM subscriptions WHERE $1 = ANY(repositories) AND created <= $2
+		ON CONFLICT (subscription_id, key) DO NOTHING`
+	stmt, err := tx.PrepareContext(ctx, q)
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to prepare the inserting deliveries query")
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to encode event")
+		}
+		if _, err := stmt.ExecContext(ctx, e.Repository, e.Time, e.Key(), data); err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to insert deliveries")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// GetPendingDeliveries returns the pending deliveries due to be attempted, the oldest first
+func (p *postgres) GetPendingDeliveries(ctx context.Context, limit int) ([]Delivery, error) {
+	q := `SELECT d.id, d.subscription_id, s.url, s.format, d.event, d.status, d.attempts, d.next_attempt, d.response_status, d.error, d.created
+		FROM deliveries d JOIN subscriptions s ON s.id = d.subscription_id
+		WHERE d.status = $1 AND d.next_attempt <= now()
+		ORDER BY d.next_attempt ASC LIMIT $2`
+	rows, err := p.db.QueryContext(ctx, q, DeliveryPending, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch pending deliveries")
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var event []byte
+		d := Delivery{}
+		if err := rows.Scan(&d.ID, &d.Subscription, &d.URL, &d.Format, &event, &d.Status, &d.Attempts, &d.NextAttempt, &d.ResponseStatus, &d.Error, &d.Created); err != nil {
+			return deliveries, errors.Wrap(err, "failed to scan delivery")
+		}
+		if err := json.Unmarshal(event, &d.Event); err != nil {
+			return deliveries, errors.Wrap(err, "failed to decode delivery event")
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return deliveries, errors.Wrap(err, "failed to retrieve pending deliveries")
+	}
+
+	return deliveries, nil
+}
+
+func (p *postgres) UpdateDelivery(ctx context.Context, d Delivery) error {
+	q := `UPDATE deliveries SET status = $2, attempts = $3, next_attempt = $4, response_status = $5, error = $6 WHERE id = $1`
+	if _, err := p.db.ExecContext(ctx, q, d.ID, d.Status, d.Attempts, d.NextAttempt, d.ResponseStatus, d.Error); err != nil {
+		return errors.Wrap(err, "failed to update delivery")
+	}
+	return nil
+}
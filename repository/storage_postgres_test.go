@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// benchmarkRepositories is the number of repositories seeded for benchmarks
+const benchmarkRepositories = 500000
+
+// seededPostgres connects to the postgres of the DSN environment variable and seeds a temporary
+// repositories table, which hides the actual one for the only connection of the returned storage.
+// Tests and benchmarks are skipped without a DSN.
+func seededPostgres(b testing.TB, repositories int) (*sql.DB, Storage) {
+	dsn := os.Getenv("DSN")
+	if dsn == "" {
+		b.Skip("DSN isn't set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+
+	q := `CREATE TEMPORARY TABLE repositories (
+		id  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		url VARCHAR(256) NOT NULL
+	)`
+	if _, err := db.Exec(q); err != nil {
+		db.Close()
+		b.Fatal(err)
+	}
+
+	q = `INSERT INTO repositories (url) SELECT 'github.com/bench/' || n FROM generate_series(1, $1) n`
+	if _, err := db.Exec(q, repositories); err != nil {
+		db.Close()
+		b.Fatal(err)
+	}
+
+	if _, err := db.Exec(`ANALYZE repositories`); err != nil {
+		db.Close()
+		b.Fatal(err)
+	}
+
+	return db, NewPostgresStorage(db)
+}
+
+func BenchmarkGetRandom(b *testing.B) {
+	db, repositories := seededPostgres(b, benchmarkRepositories)
+	defer db.Close()
+
+	ctx := context.Background()
+
+	b.Run("order by random", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rows, err := db.QueryContext(ctx, `SELECT url FROM repositories ORDER BY random() LIMIT $1`, homepageLimit)
+			if err != nil {
+				b.Fatal(err)
+			}
+			rows.Close()
+		}
+	})
+
+	b.Run("sampled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			repos, err := repositories.GetRandom(ctx, homepageLimit)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(repos) < homepageLimit-2 {
+				b.Fatalf("expected about %d repositories, got %d", homepageLimit, len(repos))
+			}
+		}
+	})
+}
+
+func TestGetRandom(t *testing.T) {
+	tests := []struct {
+		repositories int
+		limit        int
+		expected     int
+	}{
+		{repositories: 5, limit: homepageLimit, expected: 5},
+		{repositories: 20, limit: homepageLimit, expected: homepageLimit},
+		{repositories: 2 * randomSampleThreshold, limit: homepageLimit, expected: homepageLimit},
+	}
+
+	for _, tt := range tests {
+		db, repositories := seededPostgres(t, tt.repositories)
+
+		for i := 0; i < 10; i++ {
+			repos, err := repositories.GetRandom(context.Background(), tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seen := map[string]bool{}
+			for _, r := range repos {
+				seen[r] = true
+			}
+			if len(repos) != tt.expected || len(seen) != len(repos) {
+				t.Errorf("expected %d distinct of %d repositories, got %v", tt.expected, tt.repositories, repos)
+			}
+		}
+		db.Close()
+	}
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/pkg/errors"
+)
+
+// Webhooks delivers events to the webhooks of subscriptions
+type Webhooks struct {
+	client   *http.Client
+	apiCalls metrics.Histogram
+}
+
+// NewWebhooks initializes Webhooks with a http client
+func NewWebhooks(client *http.Client, apiCalls metrics.Histogram) (*Webhooks, error) {
+	wh := &Webhooks{
+		client:   client,
+		apiCalls: apiCalls.With("service", "webhooks"),
+	}
+
+	// Initialize metric with a zero value
+	wh.apiCalls.Observe(0)
+
+	return wh, nil
+}
+
+// ErrNonPublicWebhook is returned for webhooks whose host isn't a public address,
+// as godep must not be used to send requests into internal networks.
+var ErrNonPublicWebhook = errors.New("webhook host isn't a public address")
+
+// nonPublicNetworks are the networks of private, shared and reserved addresses, which
+// aren't covered by net.IP's methods for loopback, link-local and unspecified addresses.
+var nonPublicNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"240.0.0.0/4",
+		"64:ff9b::/96",
+		"fc00::/7",
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// publicIP returns if an ip is a public unicast address
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupIPAddr resolves a host's addresses, it's replaced by tests
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// checkPublicHost returns ErrNonPublicWebhook if a host resolves to any address that isn't public
+func checkPublicHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !publicIP(ip) {
+			return ErrNonPublicWebhook
+		}
+		return nil
+	}
+
+	addrs, err := lookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve %s", host)
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return ErrNonPublicWebhook
+		}
+	}
+	return nil
+}
+
+// publicDialControl refuses connections to addresses that aren't public. Checking the address
+// dialed, after it was resolved, keeps DNS rebinding from passing the check of a webhook's host.
+func publicDialControl(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return ErrNonPublicWebhook
+	}
+	return nil
+}
+
+// webhookPayload encodes the event of a delivery in the format of its webhook
+func webhookPayload(d Delivery) ([]byte, error) {
+	if d.Format == WebhookSlack {
+		return json.Marshal(struct {
+			Text string `json:"text"`
+		}{Text: d.Event.Text()})
+	}
+	return json.Marshal(d.Event)
+}
+
+// Send posts a delivery to its webhook and returns the response's status code.
+// Any response but 2xx is an error.
+func (wh *Webhooks) Send(ctx context.Context, d Delivery) (int, error) {
+	defer func(start time.Time) {
+		wh.apiCalls.Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	payload, err := webhookPayload(d)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to encode payload")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "godep.org")
+	req.Header.Set("X-Godep-Event", string(d.Event.Type))
+	req.Header.Set("X-Godep-Delivery", fmt.Sprintf("%d", d.ID))
+
+	resp, err := wh.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to do the request")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, errors.Errorf("unexpected status code from webhook: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}